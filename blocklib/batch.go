@@ -0,0 +1,144 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Reasons the orderer cut the block
+const (
+	CutMaxMessageCount = "max-message-count" // the batch reached BatchSize.MaxMessageCount
+	CutPreferredBytes  = "preferred-bytes"   // the next message would exceed BatchSize.PreferredMaxBytes (or the message exceeds it itself)
+	CutTimeout         = "timeout"           // BatchTimeout expired
+	CutConfig          = "config"            // config transactions are always ordered in a block of their own
+)
+
+// BatchConfig is the block cutting configuration of the ordering service.
+type BatchConfig struct {
+	MaxMessageCount   uint32
+	AbsoluteMaxBytes  uint32
+	PreferredMaxBytes uint32
+	Timeout           time.Duration
+}
+
+// BlockCut describes how the block was cut by the orderer.
+type BlockCut struct {
+	Reason     string
+	Messages   int     // number of transactions
+	Bytes      int     // size of transactions (payloads and signatures, as counted by the orderer)
+	FillRatio  float64 // the greatest of messages to MaxMessageCount and bytes to PreferredMaxBytes ratios, exceeds 1 for oversized messages
+	FirstTx    time.Time
+	LastTx     time.Time     // approximates the commit time of the block, which the block doesn't carry
+	Latency    time.Duration // average time from the transactions (by tx timestamps set by clients) to the commit of the block
+	MaxLatency time.Duration // time from the first transaction to the commit of the block
+}
+
+// OrdererBatchConfig extracts BatchSize and BatchTimeout of the ordering service from channel config.
+func OrdererBatchConfig(config *common.Config) (*BatchConfig, error) {
+	if config == nil || config.ChannelGroup == nil {
+		return nil, errors.New("empty channel config")
+	}
+	ordererGroup, ok := config.ChannelGroup.Groups["Orderer"]
+	if !ok {
+		return nil, errors.New("no Orderer group in channel config")
+	}
+
+	batchSizeValue, ok := ordererGroup.Values["BatchSize"]
+	if !ok {
+		return nil, errors.New("no BatchSize in Orderer group")
+	}
+	batchSize := &orderer.BatchSize{}
+	if err := proto.Unmarshal(batchSizeValue.Value, batchSize); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling BatchSize")
+	}
+	batchTimeoutValue, ok := ordererGroup.Values["BatchTimeout"]
+	if !ok {
+		return nil, errors.New("no BatchTimeout in Orderer group")
+	}
+	batchTimeout := &orderer.BatchTimeout{}
+	if err := proto.Unmarshal(batchTimeoutValue.Value, batchTimeout); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling BatchTimeout")
+	}
+	timeout, err := time.ParseDuration(batchTimeout.Timeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse BatchTimeout")
+	}
+
+	return &BatchConfig{
+		MaxMessageCount:   batchSize.MaxMessageCount,
+		AbsoluteMaxBytes:  batchSize.AbsoluteMaxBytes,
+		PreferredMaxBytes: batchSize.PreferredMaxBytes,
+		Timeout:           timeout,
+	}, nil
+}
+
+// ClassifyCut determines why the orderer cut the block using the batch configuration in force for the block.
+// The orderer cuts the batch when it reaches MaxMessageCount, when the next message doesn't fit into PreferredMaxBytes
+// or when BatchTimeout expires. The next message is unknown, so the block is considered cut by size
+// if a message of the average size of the block messages doesn't fit into it.
+func (b *Block) ClassifyCut(batch *BatchConfig) (*BlockCut, error) {
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+
+	cut := &BlockCut{Messages: len(b.Data)}
+	for _, data := range b.Data {
+		envelope := &common.Envelope{}
+		if err := proto.Unmarshal(data, envelope); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling Envelope")
+		}
+		cut.Bytes += len(envelope.Payload) + len(envelope.Signature)
+	}
+	timestamps := make([]time.Time, 0, len(txs))
+	for _, tx := range txs {
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			return nil, err
+		}
+		if cut.FirstTx.IsZero() || timestamp.Before(cut.FirstTx) {
+			cut.FirstTx = timestamp
+		}
+		if timestamp.After(cut.LastTx) {
+			cut.LastTx = timestamp
+		}
+		timestamps = append(timestamps, timestamp)
+	}
+	if len(timestamps) > 0 {
+		var latency time.Duration
+		for _, timestamp := range timestamps {
+			latency += cut.LastTx.Sub(timestamp)
+		}
+		cut.Latency = latency / time.Duration(len(timestamps))
+		cut.MaxLatency = cut.LastTx.Sub(cut.FirstTx)
+	}
+
+	if batch.MaxMessageCount > 0 {
+		cut.FillRatio = float64(cut.Messages) / float64(batch.MaxMessageCount)
+	}
+	if batch.PreferredMaxBytes > 0 {
+		if ratio := float64(cut.Bytes) / float64(batch.PreferredMaxBytes); ratio > cut.FillRatio {
+			cut.FillRatio = ratio
+		}
+	}
+
+	switch {
+	case b.IsConfig():
+		cut.Reason = CutConfig
+	case batch.MaxMessageCount > 0 && cut.Messages >= int(batch.MaxMessageCount):
+		cut.Reason = CutMaxMessageCount
+	case batch.PreferredMaxBytes > 0 && cut.Messages > 0 && cut.Bytes+cut.Bytes/cut.Messages > int(batch.PreferredMaxBytes):
+		cut.Reason = CutPreferredBytes
+	default:
+		cut.Reason = CutTimeout
+	}
+	return cut, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestOrdererBatchConfig(t *testing.T) {
+	block, err := getBlock("./mock/config.pb")
+	assert.NoError(t, err)
+	config, err := ConfigFromBlock(block)
+	assert.NoError(t, err)
+
+	batch, err := OrdererBatchConfig(config)
+	assert.NoError(t, err)
+	assert.Equal(t, &BatchConfig{
+		MaxMessageCount:   10,
+		AbsoluteMaxBytes:  103809024,
+		PreferredMaxBytes: 524288,
+		Timeout:           2 * time.Second,
+	}, batch)
+}
+
+func TestClassifyCut(t *testing.T) {
+	batch := &BatchConfig{MaxMessageCount: 10, PreferredMaxBytes: 524288, Timeout: 2 * time.Second}
+
+	cut, err := block3.ClassifyCut(batch)
+	assert.NoError(t, err)
+	assert.Equal(t, CutTimeout, cut.Reason)
+	assert.Equal(t, 5, cut.Messages)
+	assert.True(t, cut.Bytes > 0 && cut.Bytes < 23433)
+	assert.Equal(t, 0.5, cut.FillRatio)
+	// latency is counted from each transaction to the commit time (the latest tx timestamp)
+	txs, err := block3.Txs()
+	assert.NoError(t, err)
+	var latency time.Duration
+	for _, tx := range txs {
+		timestamp, err := tx.Timestamp()
+		assert.NoError(t, err)
+		latency += cut.LastTx.Sub(timestamp)
+	}
+	assert.Equal(t, latency/time.Duration(len(txs)), cut.Latency)
+	assert.Equal(t, cut.LastTx.Sub(cut.FirstTx), cut.MaxLatency)
+	assert.True(t, cut.Latency > 0 && cut.Latency < cut.MaxLatency)
+
+	cut, err = block3.ClassifyCut(&BatchConfig{MaxMessageCount: 5, PreferredMaxBytes: 524288})
+	assert.NoError(t, err)
+	assert.Equal(t, CutMaxMessageCount, cut.Reason)
+	assert.Equal(t, 1.0, cut.FillRatio)
+
+	// another message of the average size doesn't fit
+	cut, err = block3.ClassifyCut(&BatchConfig{MaxMessageCount: 10, PreferredMaxBytes: 25000})
+	assert.NoError(t, err)
+	assert.Equal(t, CutPreferredBytes, cut.Reason)
+	assert.True(t, cut.FillRatio > 0.5)
+
+	configBlock, err := getBlocklibBlock("./mock/config.pb")
+	assert.NoError(t, err)
+	cut, err = configBlock.ClassifyCut(batch)
+	assert.NoError(t, err)
+	assert.Equal(t, CutConfig, cut.Reason)
+	assert.Equal(t, time.Duration(0), cut.Latency)
+	assert.Equal(t, time.Duration(0), cut.MaxLatency)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib/smartbft"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// BFTQuorumReport contains results of the verification of the block signatures of SmartBFT consenters.
+type BFTQuorumReport struct {
+	BlockNumber uint64
+	Consenters  int              // number of consenters defined in the config in force
+	Quorum      int              // number of consenters which must sign the block
+	Signatures  []SignatureCheck // checks of all the signatures in block metadata
+	Signers     []uint64         // IDs of the consenters with valid signatures, sorted and deduplicated
+}
+
+// Satisfied returns true if the block is signed by the quorum of consenters.
+func (r *BFTQuorumReport) Satisfied() bool {
+	return r.Quorum > 0 && len(r.Signers) >= r.Quorum
+}
+
+// SmartBFTConfig extracts configuration (consenters and options) of the SmartBFT ordering service from channel config.
+func SmartBFTConfig(config *common.Config) (*smartbft.ConfigMetadata, error) {
+	consensusType, err := OrdererConsensusType(config)
+	if err != nil {
+		return nil, err
+	}
+	if consensusType.Type != ConsensusBFT {
+		return nil, fmt.Errorf("consensus type is %s, not %s", consensusType.Type, ConsensusBFT)
+	}
+	metadata := &smartbft.ConfigMetadata{}
+	if err := proto.Unmarshal(consensusType.Metadata, metadata); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling smartbft ConfigMetadata")
+	}
+	return metadata, nil
+}
+
+// BFTQuorum returns the quorum of SmartBFT cluster of n consenters: ceil((n+f+1)/2), where f = (n-1)/3
+// is the number of faulty consenters the cluster tolerates. It equals 2f+1 for clusters of 3f+1 consenters.
+func BFTQuorum(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	f := (n - 1) / 3
+	return (n + f + 2) / 2
+}
+
+// VerifyBFTQuorum verifies signatures of the block produced by BFT-orderer against identities of the consenters
+// defined in the config in force for the block (got from resolver) and checks that the valid signatures come from the quorum of consenters.
+// Consenters omit the signature header from block metadata, it is restored from the consenter identity and the signature nonce.
+// Error is returned only if the block or the config can't be decoded, failed checks are reported in BFTQuorumReport.
+func VerifyBFTQuorum(resolver ConfigBlockResolver, block *common.Block) (*BFTQuorumReport, error) {
+	b, err := FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	md, err := bftMetadata(block)
+	if err != nil {
+		return nil, err
+	}
+	consenters, err := bftConsenters(resolver, b)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BFTQuorumReport{BlockNumber: b.Number(), Consenters: len(consenters), Quorum: BFTQuorum(len(consenters))}
+	headerBytes := BlockHeaderBytes(block.Header)
+	signers := make(map[uint64]bool)
+	for i, metadataSignature := range md.Signatures {
+		check := SignatureCheck{Type: SignatureBlock, TxIndex: -1, ActionIndex: -1, Index: i, ConsenterId: metadataSignature.SignerId}
+		consenter, ok := consenters[metadataSignature.SignerId]
+		if !ok {
+			check.Error = fmt.Sprintf("signer %d is not a consenter", metadataSignature.SignerId)
+			report.Signatures = append(report.Signatures, check)
+			continue
+		}
+		identity, err := proto.Marshal(&consenter.Identity)
+		if err != nil {
+			return nil, err
+		}
+
+		sigHdr := metadataSignature.SignatureHeader
+		if len(sigHdr) == 0 {
+			if sigHdr, err = proto.Marshal(&common.SignatureHeader{Creator: identity, Nonce: metadataSignature.Nonce}); err != nil {
+				return nil, err
+			}
+		}
+		check = verifySerialized(check, identity, metadataSignature.Signature, concat(md.Value, sigHdr, headerBytes))
+		if check.Valid {
+			signers[metadataSignature.SignerId] = true
+		}
+		report.Signatures = append(report.Signatures, check)
+	}
+
+	for id := range signers {
+		report.Signers = append(report.Signers, id)
+	}
+	sort.Slice(report.Signers, func(i, j int) bool {
+		return report.Signers[i] < report.Signers[j]
+	})
+	return report, nil
+}
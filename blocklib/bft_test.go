@@ -0,0 +1,175 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/newity/crawler/blocklib/smartbft"
+	bftcommon "github.com/newity/crawler/blocklib/smartbft/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestBFTQuorum(t *testing.T) {
+	for n, quorum := range map[int]int{0: 0, 1: 1, 3: 2, 4: 3, 5: 4, 6: 4, 7: 5, 10: 7} {
+		assert.Equal(t, quorum, BFTQuorum(n), "n = %d", n)
+	}
+}
+
+func TestViewMetadata(t *testing.T) {
+	block, err := getBlock("./mock/withevents.pb")
+	assert.NoError(t, err)
+	b, err := FromFabricBlock(block)
+	assert.NoError(t, err)
+	viewMetadata, err := b.ViewMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), viewMetadata.ViewId)
+	assert.Equal(t, uint64(64), viewMetadata.LatestSequence)
+}
+
+func TestVerifyBFTQuorum(t *testing.T) {
+	block, md, resolver := newSignedBFTBlock(t)
+	report, err := VerifyBFTQuorum(resolver, block)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, report.Consenters)
+	assert.Equal(t, 5, report.Quorum)
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7}, report.Signers)
+	assert.True(t, report.Satisfied())
+	for _, check := range report.Signatures {
+		assert.True(t, check.Valid, check.Error)
+		assert.Equal(t, "OrdererMSP", check.MSPID)
+	}
+
+	// signature of consenter 4 is attributed to consenters 1 and 6, the same signer twice is counted once
+	md.Signatures[1].Signature = md.Signatures[0].Signature
+	md.Signatures[0].SignerId = 1
+	md.Signatures = append(md.Signatures, md.Signatures[2])
+	block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], err = proto.Marshal(md)
+	assert.NoError(t, err)
+
+	report, err = VerifyBFTQuorum(resolver, block)
+	assert.NoError(t, err)
+	assert.Len(t, report.Signatures, 6)
+	assert.False(t, report.Signatures[0].Valid)
+	assert.False(t, report.Signatures[1].Valid)
+	assert.Equal(t, uint64(6), report.Signatures[1].ConsenterId)
+	assert.Equal(t, []uint64{3, 5, 7}, report.Signers)
+	assert.False(t, report.Satisfied())
+
+	// signer which is not a consenter
+	md.Signatures[0].SignerId = 9
+	block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], err = proto.Marshal(md)
+	assert.NoError(t, err)
+	report, err = VerifyBFTQuorum(resolver, block)
+	assert.NoError(t, err)
+	assert.Equal(t, "signer 9 is not a consenter", report.Signatures[0].Error)
+}
+
+func TestVerifyBlockWithResolver(t *testing.T) {
+	block, md, resolver := newSignedBFTBlock(t)
+	report, err := VerifyBlockWithResolver(resolver, block)
+	assert.NoError(t, err)
+	assert.True(t, report.Valid())
+	assert.Empty(t, report.Unverifiable())
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7}, report.Quorum.Signers)
+	assert.Equal(t, uint64(4), report.Signatures[0].ConsenterId)
+
+	// blocks of other orderers don't need the resolver
+	sample, err := getBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	report, err = VerifyBlockWithResolver(resolver, sample)
+	assert.NoError(t, err)
+	assert.True(t, report.Valid())
+	assert.Nil(t, report.Quorum)
+
+	// 4 valid signatures of 7 consenters are not the quorum
+	md.Signatures = md.Signatures[1:]
+	block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], err = proto.Marshal(md)
+	assert.NoError(t, err)
+	report, err = VerifyBlockWithResolver(resolver, block)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Failures())
+	assert.False(t, report.Quorum.Satisfied())
+	assert.False(t, report.Valid())
+}
+
+// newSignedBFTBlock re-signs withevents.pb block by 7 generated consenters. 7 consenters tolerate 2 faulty ones,
+// the block is signed by consenters 4, 6, 3, 5 and 7. The config block of the consenters is put into the returned resolver.
+func newSignedBFTBlock(t *testing.T) (*common.Block, *bftcommon.BFTMetadata, *CachingConfigResolver) {
+	block, err := getBlock("./mock/withevents.pb")
+	assert.NoError(t, err)
+	md := &bftcommon.BFTMetadata{}
+	assert.NoError(t, proto.Unmarshal(block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], md))
+
+	keys := make(map[uint64]*ecdsa.PrivateKey)
+	var consenters []*smartbft.Consenter
+	for id := uint64(1); id <= 7; id++ {
+		key, cert := newConsenterCert(t, id)
+		keys[id] = key
+		identity, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "OrdererMSP", IdBytes: cert})
+		assert.NoError(t, err)
+		consenters = append(consenters, &smartbft.Consenter{ConsenterId: id, MspId: "OrdererMSP", Identity: identity})
+	}
+	resolver := NewCachingConfigResolver(nil)
+	resolver.Put("cc", newSmartBFTConfigBlockWithConsenters(t, "cc", 4, consenters))
+
+	for _, signature := range md.Signatures {
+		sigHdr, err := proto.Marshal(&common.SignatureHeader{Creator: consenters[signature.SignerId-1].Identity, Nonce: signature.Nonce})
+		assert.NoError(t, err)
+		signature.Signature = signECDSA(t, keys[signature.SignerId], concat(md.Value, sigHdr, BlockHeaderBytes(block.Header)))
+	}
+	block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], err = proto.Marshal(md)
+	assert.NoError(t, err)
+	return block, md, resolver
+}
+
+// newConsenterCert creates self-signed certificate of the consenter and returns its key and pem-encoded certificate.
+func newConsenterCert(t *testing.T, id uint64) (*ecdsa.PrivateKey, []byte) {
+	return newECDSACert(t, elliptic.P256(), id)
+}
+
+// newECDSACert creates self-signed certificate with the key on the curve and returns the key and pem-encoded certificate.
+func newECDSACert(t *testing.T, curve elliptic.Curve, id uint64) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	assert.NoError(t, err)
+	notBefore := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(id)),
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("orderer%d.example.com", id)},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// signECDSA signs SHA256 digest of the message and returns low-S ASN.1 signature, as Fabric MSP identities do.
+func signECDSA(t *testing.T, key *ecdsa.PrivateKey, message []byte) []byte {
+	digest := sha256.Sum256(message)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	if s.Cmp(new(big.Int).Rsh(key.Params().N, 1)) > 0 {
+		s.Sub(key.Params().N, s)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	assert.NoError(t, err)
+	return signature
+}
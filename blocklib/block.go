@@ -13,15 +13,12 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/msp"
-	"github.com/hyperledger/fabric-protos-go/orderer"
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/newity/crawler/blocklib/smartbft"
 	bftcommon "github.com/newity/crawler/blocklib/smartbft/common"
 	"github.com/pkg/errors"
 	"math/big"
-	"unsafe"
 )
 
 // Block contains all the necessary information about the blockchain block
@@ -39,10 +36,11 @@ type Block struct {
 
 // BlockSignature contains nonce, cert, MSP ID and signature of the orderer which signed the block
 type BlockSignature struct {
-	Cert      []byte // pem-encoded
-	MSPID     string
-	Signature []byte
-	Nonce     []byte
+	Cert        []byte // pem-encoded
+	MSPID       string
+	Signature   []byte
+	Nonce       []byte
+	ConsenterId uint64 // ID of the BFT consenter (BFT blocks only)
 }
 
 type BFTSerializedIdentity struct {
@@ -120,74 +118,86 @@ func CheckIntegrity(previousblock, currentblock *Block) bool {
 }
 
 // FromBFTFabricBlock converts common.Block produced by BFT-orderer to blocklib.Block.
+// The last config block (with consenters identities) is queried from the peer using ledger client.
 func FromBFTFabricBlock(cli *ledger.Client, block *common.Block) (*Block, error) {
-	metadata := &common.Metadata{}
-	err := proto.Unmarshal(block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], metadata)
+	return FromBFTFabricBlockWithResolver(NewLedgerConfigResolver(cli), block)
+}
+
+// FromBFTFabricBlockWithResolver converts common.Block produced by BFT-orderer to blocklib.Block.
+// BFT signatures contain consenter IDs instead of identities, so the last config block is got from resolver
+// to attribute each signature to the consenter which made it.
+// If the signer is not found among consenters, the signature has no cert and MSP ID.
+func FromBFTFabricBlockWithResolver(resolver ConfigBlockResolver, block *common.Block) (*Block, error) {
+	b, err := FromFabricBlock(block)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error unmarshaling metadata from block at index [%s]", common.BlockMetadataIndex_SIGNATURES)
+		return nil, err
 	}
 
-	identities, err := GetBFTOrderersIdentities(cli, block)
+	md, err := bftMetadata(block)
+	if err != nil {
+		return nil, err
+	}
+	consenters, err := bftConsenters(resolver, b)
 	if err != nil {
 		return nil, err
 	}
 
 	var blockSignatures []BlockSignature
-	for _, metadataSignature := range metadata.Signatures {
+	for _, metadataSignature := range md.Signatures {
 		sigHdr := &common.SignatureHeader{}
 		if err := proto.Unmarshal(metadataSignature.SignatureHeader, sigHdr); err != nil {
 			return nil, errors.Wrap(err, "error unmarshaling SignatureHeader")
 		}
-
-		creator := &msp.SerializedIdentity{}
-		if err = proto.Unmarshal(sigHdr.Creator, creator); err != nil {
-			return nil, err
+		signature := BlockSignature{
+			Signature:   metadataSignature.Signature,
+			Nonce:       metadataSignature.Nonce,
+			ConsenterId: metadataSignature.SignerId,
+		}
+		if len(signature.Nonce) == 0 {
+			signature.Nonce = sigHdr.Nonce
 		}
-		for _, identity := range identities {
-			blockSignatures = append(blockSignatures,
-				BlockSignature{
-					Cert:      identity.Identity.IdBytes,
-					MSPID:     identity.Identity.Mspid,
-					Signature: metadataSignature.Signature,
-					Nonce:     sigHdr.Nonce,
-				},
-			)
+		if identity, ok := consenters[metadataSignature.SignerId]; ok {
+			signature.Cert = identity.Identity.IdBytes
+			signature.MSPID = identity.Identity.Mspid
 		}
+		blockSignatures = append(blockSignatures, signature)
 	}
+	b.signatures = blockSignatures
+	return b, nil
+}
 
-	envelope := &common.Envelope{}
-	if err := proto.Unmarshal(block.Data.Data[0], envelope); err != nil {
+// GetBFTOrderersIdentities returns identities of the consenters which signed the block produced by BFT-orderer (one per signature).
+// The last config block is queried from the peer using ledger client.
+func GetBFTOrderersIdentities(cli *ledger.Client, blk *common.Block) ([]BFTSerializedIdentity, error) {
+	return GetBFTOrderersIdentitiesWithResolver(NewLedgerConfigResolver(cli), blk)
+}
+
+// GetBFTOrderersIdentitiesWithResolver returns identities of the consenters which signed the block produced by BFT-orderer (one per signature).
+// The last config block is got from resolver. Signers which are not found among consenters are skipped.
+func GetBFTOrderersIdentitiesWithResolver(resolver ConfigBlockResolver, blk *common.Block) ([]BFTSerializedIdentity, error) {
+	md, err := bftMetadata(blk)
+	if err != nil {
 		return nil, err
 	}
-
-	payload := &common.Payload{}
-	err = proto.Unmarshal(envelope.Payload, payload)
+	b, err := FromFabricBlock(blk)
 	if err != nil {
 		return nil, err
 	}
-
-	hdr := &common.ChannelHeader{}
-	err = proto.Unmarshal(payload.Header.ChannelHeader, hdr)
+	consenters, err := bftConsenters(resolver, b)
 	if err != nil {
 		return nil, err
 	}
 
-	headerHash := sha256.Sum256(BlockHeaderBytes(block.Header))
-
-	return &Block{
-		Data:       block.Data.Data,
-		number:     block.Header.Number,
-		signatures: blockSignatures,
-		Metadata:   block.Metadata.Metadata,
-		prevhash:   block.Header.PreviousHash,
-		datahash:   block.Header.DataHash,
-		headerhash: headerHash[:],
-		txsFilter:  block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER],
-		isconfig:   common.HeaderType(hdr.Type) == common.HeaderType_CONFIG || common.HeaderType(hdr.Type) == common.HeaderType_ORDERER_TRANSACTION,
-	}, nil
+	var identities []BFTSerializedIdentity
+	for _, signature := range md.Signatures {
+		if identity, ok := consenters[signature.SignerId]; ok {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
 }
 
-func GetBFTOrderersIdentities(cli *ledger.Client, blk *common.Block) ([]BFTSerializedIdentity, error) {
+func bftMetadata(blk *common.Block) (*bftcommon.BFTMetadata, error) {
 	if blk.Metadata == nil {
 		return nil, errors.New("no metadata in block")
 	}
@@ -202,50 +212,53 @@ func GetBFTOrderersIdentities(cli *ledger.Client, blk *common.Block) ([]BFTSeria
 	if err := proto.Unmarshal(blk.Metadata.Metadata[index], md); err != nil {
 		return nil, fmt.Errorf("couldn't unmarshal block metadata: %w", err)
 	}
+	return md, nil
+}
 
-	identities := make([]BFTSerializedIdentity, 0, len(md.Signatures))
-	lc := common.LastConfig{}
-	if err := proto.Unmarshal(md.Value, &lc); err == nil {
-		if cfgBlock, err := cli.QueryBlock(lc.Index); err == nil {
-			if configEnvelope, err := resource.CreateConfigEnvelope(cfgBlock.Data.Data[0]); err == nil {
-				identities, err = getOrderersIdentities((*common.ConfigEnvelope)(unsafe.Pointer(configEnvelope)))
-				if err != nil {
-					return nil, fmt.Errorf("couldn't extract orderers identities: %w", err)
-				}
-			}
-		}
+// bftConsenters returns identities of the consenters defined in the last config block of the block: consenter ID => identity.
+func bftConsenters(resolver ConfigBlockResolver, b *Block) (map[uint64]BFTSerializedIdentity, error) {
+	lastConfig, err := b.LastConfig()
+	if err != nil {
+		return nil, err
 	}
-
-	// filter 'identities' slice (all channel orderers) to find those which signed this block
-	var filteredIdentities []BFTSerializedIdentity
-	for _, identity := range identities {
-		for _, signature := range md.Signatures {
-			if identity.ConsenterId == signature.SignerId {
-				filteredIdentities = append(filteredIdentities, identity)
-			}
-		}
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+	header, err := txs[0].ChannelHeader()
+	if err != nil {
+		return nil, err
 	}
-	return filteredIdentities, nil
-}
-
-func getOrderersIdentities(envelope *common.ConfigEnvelope) ([]BFTSerializedIdentity, error) {
-	consensusType := envelope.Config.ChannelGroup.Groups["Orderer"].Values["ConsensusType"].Value
 
-	ct := &orderer.ConsensusType{}
-	err := proto.Unmarshal(consensusType, ct)
+	cfgBlock, err := resolver.ConfigBlock(header.ChannelId, lastConfig)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get config block %d: %w", lastConfig, err)
+	}
+	config, err := ConfigFromBlock(cfgBlock)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := getOrderersIdentities(config)
 	if err != nil {
-		return nil, fmt.Errorf("Failed unmarshaling ConsensusType from consensusType: %v", err)
+		return nil, fmt.Errorf("couldn't extract orderers identities: %w", err)
 	}
 
-	m := &smartbft.ConfigMetadata{}
-	err = proto.Unmarshal(ct.Metadata, m)
+	consenters := make(map[uint64]BFTSerializedIdentity)
+	for _, identity := range identities {
+		consenters[identity.ConsenterId] = identity
+	}
+	return consenters, nil
+}
+
+func getOrderersIdentities(config *common.Config) ([]BFTSerializedIdentity, error) {
+	m, err := SmartBFTConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("Failed unmarshaling ConfigMetadata from metadata: %v", err)
+		return nil, err
 	}
 
-	identity := msp.SerializedIdentity{}
 	var identities []BFTSerializedIdentity
 	for _, consenter := range m.Consenters {
+		identity := msp.SerializedIdentity{}
 		if err = proto.Unmarshal(consenter.Identity, &identity); err != nil {
 			return nil, err
 		}
@@ -309,12 +322,53 @@ func (b *Block) Txs() ([]Tx, error) {
 }
 
 // LastConfig returns last configuration block index for provided block.
+// Since Fabric v2.0 the index is stored in the orderer metadata (signatures metadata value),
+// the LAST_CONFIG metadata is used for blocks produced by earlier versions.
 func (b *Block) LastConfig() (uint64, error) {
+	ordererMetadata, err := b.ordererBlockMetadata()
+	if err != nil {
+		return 0, err
+	}
+	if ordererMetadata.LastConfig != nil {
+		return ordererMetadata.LastConfig.Index, nil
+	}
+
+	metadata := &common.Metadata{}
+	if err := proto.Unmarshal(b.Metadata[common.BlockMetadataIndex_LAST_CONFIG], metadata); err != nil {
+		return 0, errors.Wrapf(err, "error unmarshaling metadata from block at index [%s]", common.BlockMetadataIndex_LAST_CONFIG)
+	}
 	lastConfig := &common.LastConfig{}
-	err := proto.Unmarshal(b.Metadata[common.BlockMetadataIndex_LAST_CONFIG], lastConfig)
+	err = proto.Unmarshal(metadata.Value, lastConfig)
 	return lastConfig.Index, err
 }
 
+// ViewMetadata returns SmartBFT view metadata of the block produced by BFT-orderer (the consenter metadata of the orderer metadata).
+func (b *Block) ViewMetadata() (*smartbft.ViewMetadata, error) {
+	ordererMetadata, err := b.ordererBlockMetadata()
+	if err != nil {
+		return nil, err
+	}
+	viewMetadata := &smartbft.ViewMetadata{}
+	if err = proto.Unmarshal(ordererMetadata.ConsenterMetadata, viewMetadata); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling ViewMetadata")
+	}
+	return viewMetadata, nil
+}
+
+// ordererBlockMetadata returns the orderer metadata stored as the value of the signatures metadata.
+// Blocks produced by Fabric prior to v2.0 have no orderer metadata (empty value), empty one is returned for them.
+func (b *Block) ordererBlockMetadata() (*common.OrdererBlockMetadata, error) {
+	signatures := &common.Metadata{}
+	if err := proto.Unmarshal(b.Metadata[common.BlockMetadataIndex_SIGNATURES], signatures); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshaling metadata from block at index [%s]", common.BlockMetadataIndex_SIGNATURES)
+	}
+	ordererMetadata := &common.OrdererBlockMetadata{}
+	if err := proto.Unmarshal(signatures.Value, ordererMetadata); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling OrdererBlockMetadata")
+	}
+	return ordererMetadata, nil
+}
+
 func GetTx(block *common.Block, txNumber int) *Tx {
 	txsFilter := TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
 	var (
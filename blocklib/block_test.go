@@ -101,7 +101,24 @@ func TestLastConfig(t *testing.T) {
 	assert.NoError(t, err)
 	block, err := FromFabricBlock(fabBlock)
 	lastConfig, err := block.LastConfig()
-	assert.Equal(t, uint64(0), lastConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), lastConfig)
+
+	// config block is the last config for itself
+	configBlock, err := getBlocklibBlock("./mock/config.pb")
+	assert.NoError(t, err)
+	lastConfig, err = configBlock.LastConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), lastConfig)
+
+	// malformed orderer metadata is an error, not the last config 0
+	signatures, err := proto.Marshal(&common.Metadata{Value: []byte{0xff}})
+	assert.NoError(t, err)
+	block.Metadata[common.BlockMetadataIndex_SIGNATURES] = signatures
+	_, err = block.LastConfig()
+	assert.Error(t, err)
+	_, err = block.ViewMetadata()
+	assert.Error(t, err)
 }
 
 func TestNumber(t *testing.T) {
@@ -0,0 +1,217 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// Kinds of config elements
+const (
+	ConfigElementGroup  = "group"
+	ConfigElementValue  = "value"
+	ConfigElementPolicy = "policy"
+)
+
+// ConfigSignature contains identity of the admin who signed config update, the signature and nonce.
+type ConfigSignature struct {
+	Cert      []byte // pem-encoded
+	MSPID     string
+	Signature []byte
+	Nonce     []byte
+}
+
+// ConfigModification describes config element modified by config update and the policy which governs this modification.
+type ConfigModification struct {
+	Path      string // fully-qualified path of the element, e.g. /Channel/Application/Org1MSP
+	Kind      string // group, value or policy
+	Version   uint64 // new version of the element
+	ModPolicy string // fully-qualified path of the mod_policy taken from the previous config, e.g. /Channel/Application/Admins
+	Satisfied bool   // true if signers of the config update satisfy ModPolicy
+	Error     string // why the policy could not be evaluated (if it could not)
+}
+
+// ConfigUpdateAttribution tells who signed config update and whether the signers were allowed to make it.
+type ConfigUpdateAttribution struct {
+	Signatures    []ConfigSignature
+	Modifications []ConfigModification
+}
+
+// Satisfied returns true if mod_policy of every modified config element is satisfied.
+func (a *ConfigUpdateAttribution) Satisfied() bool {
+	for _, modification := range a.Modifications {
+		if !modification.Satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigUpdateEnvelope returns pointer to common.ConfigUpdateEnvelope of the last config update.
+// common.ConfigUpdateEnvelope contains marshaled config update and signatures of the admins who approved it.
+func (tx *Tx) ConfigUpdateEnvelope() (*common.ConfigUpdateEnvelope, error) {
+	configEnvelope, err := tx.ConfigEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	if configEnvelope.LastUpdate == nil {
+		return nil, errors.New("no last update in config envelope")
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(configEnvelope.LastUpdate.Payload, payload); err != nil {
+		return nil, err
+	}
+	updateEnvelope := &common.ConfigUpdateEnvelope{}
+	if err := proto.Unmarshal(payload.Data, updateEnvelope); err != nil {
+		return nil, err
+	}
+	return updateEnvelope, nil
+}
+
+// ConfigUpdateSignatures decodes every signature of the last config update into signer's MSP ID and certificate.
+func (tx *Tx) ConfigUpdateSignatures() ([]ConfigSignature, error) {
+	updateEnvelope, err := tx.ConfigUpdateEnvelope()
+	if err != nil {
+		return nil, err
+	}
+
+	var signatures []ConfigSignature
+	for _, configSignature := range updateEnvelope.Signatures {
+		sigHdr := &common.SignatureHeader{}
+		if err := proto.Unmarshal(configSignature.SignatureHeader, sigHdr); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling SignatureHeader")
+		}
+		creator := &msp.SerializedIdentity{}
+		if err := proto.Unmarshal(sigHdr.Creator, creator); err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, ConfigSignature{
+			Cert:      creator.IdBytes,
+			MSPID:     creator.Mspid,
+			Signature: configSignature.Signature,
+			Nonce:     sigHdr.Nonce,
+		})
+	}
+	return signatures, nil
+}
+
+// ConfigUpdateAttribution decodes signers of the last config update and checks them against mod_policy of every modified element.
+// 'previous' is the channel config in force before this config transaction (it can be obtained from the previous config block).
+// Signatures themselves are not verified, only the principals are matched.
+func (tx *Tx) ConfigUpdateAttribution(previous *common.Config) (*ConfigUpdateAttribution, error) {
+	if previous == nil || previous.ChannelGroup == nil {
+		return nil, errors.New("previous config is required")
+	}
+	signatures, err := tx.ConfigUpdateSignatures()
+	if err != nil {
+		return nil, err
+	}
+	update, err := tx.ConfigUpdate()
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]*msp.SerializedIdentity, 0, len(signatures))
+	for _, signature := range signatures {
+		identities = append(identities, &msp.SerializedIdentity{Mspid: signature.MSPID, IdBytes: signature.Cert})
+	}
+
+	previousElements := make(map[string]configElement)
+	flattenConfigGroup(previous.ChannelGroup, nil, channelGroupKey, previousElements)
+
+	attribution := &ConfigUpdateAttribution{Signatures: signatures}
+	for _, element := range configDelta(update.ReadSet, update.WriteSet) {
+		modification := ConfigModification{Path: element.path, Kind: element.kind, Version: element.version}
+		existing, ok := previousElements[element.id()]
+		if !ok {
+			// new elements are governed by mod_policy of the parent group which has to be modified too
+			continue
+		}
+		modification.ModPolicy = modPolicyPath(existing)
+		if existing.modPolicy == "" {
+			modification.Error = "mod_policy is not set"
+		} else if modification.Satisfied, err = EvaluatePolicy(previous, modification.ModPolicy, identities); err != nil {
+			modification.Error = err.Error()
+		}
+		attribution.Modifications = append(attribution.Modifications, modification)
+	}
+	return attribution, nil
+}
+
+type configElement struct {
+	kind      string
+	path      string   // fully-qualified path of the element
+	parent    []string // path of the parent group
+	version   uint64
+	modPolicy string
+}
+
+func (e configElement) id() string {
+	return e.kind + ":" + e.path
+}
+
+// flattenConfigGroup returns all elements of the config group (including the group itself) by ids.
+func flattenConfigGroup(group *common.ConfigGroup, parent []string, key string, result map[string]configElement) {
+	path := append(append([]string{}, parent...), key)
+	groupElement := configElement{kind: ConfigElementGroup, path: joinPath(path), parent: parent, version: group.Version, modPolicy: group.ModPolicy}
+	result[groupElement.id()] = groupElement
+
+	for name, value := range group.Values {
+		element := configElement{kind: ConfigElementValue, path: joinPath(append(path, name)), parent: path, version: value.Version, modPolicy: value.ModPolicy}
+		result[element.id()] = element
+	}
+	for name, policy := range group.Policies {
+		element := configElement{kind: ConfigElementPolicy, path: joinPath(append(path, name)), parent: path, version: policy.Version, modPolicy: policy.ModPolicy}
+		result[element.id()] = element
+	}
+	for name, subgroup := range group.Groups {
+		flattenConfigGroup(subgroup, path, name, result)
+	}
+}
+
+// configDelta returns elements of the write set which are absent in the read set or have different version (as Fabric computes it).
+func configDelta(readSet, writeSet *common.ConfigGroup) []configElement {
+	reads, writes := make(map[string]configElement), make(map[string]configElement)
+	if readSet != nil {
+		flattenConfigGroup(readSet, nil, channelGroupKey, reads)
+	}
+	if writeSet != nil {
+		flattenConfigGroup(writeSet, nil, channelGroupKey, writes)
+	}
+
+	var delta []configElement
+	for id, element := range writes {
+		if read, ok := reads[id]; ok && read.version == element.version {
+			continue
+		}
+		delta = append(delta, element)
+	}
+	sort.Slice(delta, func(i, j int) bool {
+		return delta[i].id() < delta[j].id()
+	})
+	return delta
+}
+
+// modPolicyPath resolves mod_policy of the element to the fully-qualified policy path.
+// Relative mod_policy of a group is resolved against the group itself, of a value or policy - against the parent group.
+func modPolicyPath(element configElement) string {
+	if element.modPolicy == "" || strings.HasPrefix(element.modPolicy, "/") {
+		return element.modPolicy
+	}
+	if element.kind == ConfigElementGroup {
+		return element.path + "/" + element.modPolicy
+	}
+	return joinPath(element.parent) + "/" + element.modPolicy
+}
+
+func joinPath(elements []string) string {
+	return "/" + strings.Join(elements, "/")
+}
@@ -0,0 +1,129 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfigUpdateSignatures(t *testing.T) {
+	signatures, err := configtx.ConfigUpdateSignatures()
+	assert.NoError(t, err)
+	assert.Len(t, signatures, 2)
+
+	var mspids []string
+	for _, signature := range signatures {
+		assert.NotEmpty(t, signature.Cert)
+		assert.NotEmpty(t, signature.Signature)
+		assert.NotEmpty(t, signature.Nonce)
+		mspids = append(mspids, signature.MSPID)
+	}
+	assert.ElementsMatch(t, []string{"Org1MSP", "Org2MSP"}, mspids)
+}
+
+func TestConfigUpdateAttribution(t *testing.T) {
+	previous, err := configUpdateTx.ConfigEnvelope()
+	assert.NoError(t, err)
+
+	t.Run("happy path", func(t *testing.T) {
+		attribution, err := configtx.ConfigUpdateAttribution(previous.Config)
+		assert.NoError(t, err)
+		assert.Len(t, attribution.Signatures, 2)
+		assert.Equal(t, []ConfigModification{{
+			Path:      "/Channel/Application",
+			Kind:      ConfigElementGroup,
+			Version:   2,
+			ModPolicy: "/Channel/Application/Admins",
+			Satisfied: true,
+		}}, attribution.Modifications)
+		assert.True(t, attribution.Satisfied())
+	})
+
+	t.Run("anchor peers update", func(t *testing.T) {
+		txs, err := block1.Txs()
+		assert.NoError(t, err)
+		previous, err := txs[0].ConfigEnvelope()
+		assert.NoError(t, err)
+
+		attribution, err := configUpdateTx.ConfigUpdateAttribution(previous.Config)
+		assert.NoError(t, err)
+		assert.Len(t, attribution.Signatures, 1)
+		assert.Equal(t, "Org2MSP", attribution.Signatures[0].MSPID)
+		assert.Equal(t, []ConfigModification{{
+			Path:      "/Channel/Application/Org2MSP",
+			Kind:      ConfigElementGroup,
+			Version:   1,
+			ModPolicy: "/Channel/Application/Org2MSP/Admins",
+			Satisfied: true,
+		}}, attribution.Modifications)
+	})
+
+	t.Run("no previous config", func(t *testing.T) {
+		_, err := configtx.ConfigUpdateAttribution(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	previous, err := configUpdateTx.ConfigEnvelope()
+	assert.NoError(t, err)
+	signatures, err := configtx.ConfigUpdateSignatures()
+	assert.NoError(t, err)
+
+	var org1Admin, org2Admin *msp.SerializedIdentity
+	for _, signature := range signatures {
+		identity := &msp.SerializedIdentity{Mspid: signature.MSPID, IdBytes: signature.Cert}
+		if signature.MSPID == "Org1MSP" {
+			org1Admin = identity
+		} else {
+			org2Admin = identity
+		}
+	}
+
+	// MAJORITY of two organizations' admins is required
+	satisfied, err := EvaluatePolicy(previous.Config, "/Channel/Application/Admins", []*msp.SerializedIdentity{org2Admin})
+	assert.NoError(t, err)
+	assert.False(t, satisfied)
+
+	satisfied, err = EvaluatePolicy(previous.Config, "/Channel/Application/Admins", []*msp.SerializedIdentity{org1Admin, org2Admin})
+	assert.NoError(t, err)
+	assert.True(t, satisfied)
+
+	// admin of the other org doesn't satisfy the org policy
+	satisfied, err = EvaluatePolicy(previous.Config, "/Channel/Application/Org1MSP/Admins", []*msp.SerializedIdentity{org2Admin})
+	assert.NoError(t, err)
+	assert.False(t, satisfied)
+
+	_, err = EvaluatePolicy(previous.Config, "/Channel/Application/Unknown", []*msp.SerializedIdentity{org2Admin})
+	assert.Error(t, err)
+
+	// organization without the sub-policy counts as not satisfied
+	config := proto.Clone(previous.Config).(*common.Config)
+	delete(config.ChannelGroup.Groups["Application"].Groups["Org1MSP"].Policies, "Admins")
+	satisfied, err = EvaluatePolicy(config, "/Channel/Application/Admins", []*msp.SerializedIdentity{org1Admin, org2Admin})
+	assert.NoError(t, err)
+	assert.False(t, satisfied)
+
+	// group without subgroups satisfies its ImplicitMeta policies without signatures
+	config.ChannelGroup.Groups["Application"].Groups = nil
+	satisfied, err = EvaluatePolicy(config, "/Channel/Application/Admins", nil)
+	assert.NoError(t, err)
+	assert.True(t, satisfied)
+}
+
+func TestImplicitMetaThreshold(t *testing.T) {
+	for _, rule := range []common.ImplicitMetaPolicy_Rule{common.ImplicitMetaPolicy_ANY, common.ImplicitMetaPolicy_ALL, common.ImplicitMetaPolicy_MAJORITY} {
+		assert.Equal(t, 0, implicitMetaThreshold(rule, 0), rule.String())
+	}
+	assert.Equal(t, 1, implicitMetaThreshold(common.ImplicitMetaPolicy_ANY, 3))
+	assert.Equal(t, 3, implicitMetaThreshold(common.ImplicitMetaPolicy_ALL, 3))
+	assert.Equal(t, 2, implicitMetaThreshold(common.ImplicitMetaPolicy_MAJORITY, 3))
+	assert.Equal(t, 2, implicitMetaThreshold(common.ImplicitMetaPolicy_MAJORITY, 2))
+}
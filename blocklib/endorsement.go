@@ -0,0 +1,251 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// ValidationParameterKey is the name of the key metadata entry which holds state-based (key-level) endorsement policy.
+const ValidationParameterKey = "VALIDATION_PARAMETER"
+
+// Channel config policies used by Fabric by default
+const (
+	DefaultEndorsementPolicy          = "/Channel/Application/Endorsement"
+	DefaultLifecycleEndorsementPolicy = "/Channel/Application/LifecycleEndorsement"
+)
+
+// Sources of the endorsement policies
+const (
+	PolicySourceChaincode = "chaincode" // chaincode definition (or the channel config policy it refers to)
+	PolicySourceKeyLevel  = "key-level" // state-based endorsement policy of the written keys
+)
+
+// EndorsementPolicy is an endorsement policy of the chaincode: either signature policy or reference to the channel config policy.
+type EndorsementPolicy struct {
+	SignaturePolicy     *common.SignaturePolicyEnvelope
+	ChannelConfigPolicy string // e.g. /Channel/Application/Endorsement
+}
+
+// ChaincodeEndorsementPolicy returns endorsement policy set by the chaincode definition.
+// Definitions committed via _lifecycle without explicit policy use DefaultEndorsementPolicy.
+// Fabric 1.x configs have no such policy: LSCC definitions use their signature policy, and if it is omitted
+// LSCC signs by any member of the channel organizations, which is not known from the definition, so nil is returned.
+func (d *ChaincodeDefinition) ChaincodeEndorsementPolicy() *EndorsementPolicy {
+	if d.Operation == LifecycleDeploy || d.Operation == LifecycleUpgrade {
+		if d.EndorsementPolicy == nil {
+			return nil
+		}
+		return &EndorsementPolicy{SignaturePolicy: d.EndorsementPolicy}
+	}
+	if d.EndorsementPolicy == nil && d.ChannelConfigPolicy == "" {
+		return &EndorsementPolicy{ChannelConfigPolicy: DefaultEndorsementPolicy}
+	}
+	return &EndorsementPolicy{SignaturePolicy: d.EndorsementPolicy, ChannelConfigPolicy: d.ChannelConfigPolicy}
+}
+
+// Evaluate checks whether the identities satisfy the policy.
+// Channel config is required for channel config policies, it is also used to determine roles of the identities.
+func (p *EndorsementPolicy) Evaluate(identities []*msp.SerializedIdentity, config *common.Config) (bool, error) {
+	if p.SignaturePolicy != nil {
+		var msps map[string]*msp.FabricMSPConfig
+		if config != nil {
+			var err error
+			if msps, err = MSPConfigs(config); err != nil {
+				return false, err
+			}
+		}
+		return EvaluateSignaturePolicy(p.SignaturePolicy, identities, msps), nil
+	}
+	if p.ChannelConfigPolicy == "" {
+		return false, errors.New("empty endorsement policy")
+	}
+	if config == nil {
+		return false, fmt.Errorf("channel config is required to evaluate %s", p.ChannelConfigPolicy)
+	}
+	return EvaluatePolicy(config, p.ChannelConfigPolicy, identities)
+}
+
+// PolicyEvaluation is a result of the evaluation of a single endorsement policy the action is subject to.
+type PolicyEvaluation struct {
+	Source    string // PolicySourceChaincode or PolicySourceKeyLevel
+	Namespace string
+	Keys      []string // written keys governed by the policy
+	Satisfied bool
+	Error     string
+}
+
+// EndorsementCoverage describes which organizations endorsed the action and whether they satisfy the policies it is subject to.
+type EndorsementCoverage struct {
+	Chaincode     string
+	EndorsingOrgs []string // MSP IDs of the endorsers, sorted and deduplicated
+	Policies      []PolicyEvaluation
+}
+
+// Satisfied returns true if all the policies the action is subject to are satisfied.
+func (c *EndorsementCoverage) Satisfied() bool {
+	if len(c.Policies) == 0 {
+		return false
+	}
+	for _, policy := range c.Policies {
+		if !policy.Satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+// ChaincodePolicyLookup returns endorsement policy of the chaincode (namespace), nil if the policy is unknown.
+type ChaincodePolicyLookup func(namespace string) *EndorsementPolicy
+
+// KeyPolicyLookup returns state-based endorsement policy of the key in the namespace, nil if the key has no such policy.
+type KeyPolicyLookup func(namespace, key string) *common.SignaturePolicyEnvelope
+
+// Endorsers returns serialized identities of the action endorsers.
+func (a *Action) Endorsers() ([]*msp.SerializedIdentity, error) {
+	var endorsers []*msp.SerializedIdentity
+	for _, endorsement := range a.Endorsements() {
+		endorser := &msp.SerializedIdentity{}
+		if err := proto.Unmarshal(endorsement.Endorser, endorser); err != nil {
+			return nil, err
+		}
+		endorsers = append(endorsers, endorser)
+	}
+	return endorsers, nil
+}
+
+// KeyLevelPolicies returns state-based endorsement policies set by the action: namespace => key => policy.
+// nil policy means that the key-level policy of the key is removed.
+func (a *Action) KeyLevelPolicies() (map[string]map[string]*common.SignaturePolicyEnvelope, error) {
+	rwsets, err := a.RWSets()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[string]map[string]*common.SignaturePolicyEnvelope)
+	for _, rwset := range rwsets {
+		for _, metadataWrite := range rwset.KVRWSet.MetadataWrites {
+			var policy *common.SignaturePolicyEnvelope
+			for _, entry := range metadataWrite.Entries {
+				if entry.Name != ValidationParameterKey || len(entry.Value) == 0 {
+					continue
+				}
+				policy = &common.SignaturePolicyEnvelope{}
+				if err := proto.Unmarshal(entry.Value, policy); err != nil {
+					return nil, err
+				}
+			}
+			if policies[rwset.NameSpace] == nil {
+				policies[rwset.NameSpace] = make(map[string]*common.SignaturePolicyEnvelope)
+			}
+			policies[rwset.NameSpace][metadataWrite.Key] = policy
+		}
+	}
+	return policies, nil
+}
+
+// EndorsementCoverage evaluates endorsements of the action against the policies it is subject to (the way Fabric validates it):
+// writes (and metadata writes) to the keys with state-based endorsement policy must satisfy the key-level policies,
+// other writes must satisfy the endorsement policy of the chaincode which owns the namespace.
+// The action without writes must satisfy the policy of the invoked chaincode.
+// Config is optional, it is required for channel config policies and role-based principals other than MEMBER.
+func (a *Action) EndorsementCoverage(chaincodePolicies ChaincodePolicyLookup, keyPolicies KeyPolicyLookup, config *common.Config) (*EndorsementCoverage, error) {
+	ccAction, err := a.ChaincodeAction()
+	if err != nil {
+		return nil, err
+	}
+	endorsers, err := a.Endorsers()
+	if err != nil {
+		return nil, err
+	}
+	rwsets, err := a.RWSets()
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := &EndorsementCoverage{Chaincode: ccAction.GetChaincodeId().GetName()}
+	for _, endorser := range endorsers {
+		coverage.EndorsingOrgs = append(coverage.EndorsingOrgs, endorser.Mspid)
+	}
+	coverage.EndorsingOrgs = uniqueSorted(coverage.EndorsingOrgs)
+
+	evaluate := func(evaluation PolicyEvaluation, policy *EndorsementPolicy) {
+		if policy == nil {
+			evaluation.Error = "endorsement policy is unknown"
+		} else if satisfied, err := policy.Evaluate(endorsers, config); err != nil {
+			evaluation.Error = err.Error()
+		} else {
+			evaluation.Satisfied = satisfied
+		}
+		coverage.Policies = append(coverage.Policies, evaluation)
+	}
+
+	for _, rwset := range rwsets {
+		var keys []string
+		for _, write := range rwset.KVRWSet.Writes {
+			keys = append(keys, write.Key)
+		}
+		for _, metadataWrite := range rwset.KVRWSet.MetadataWrites {
+			keys = append(keys, metadataWrite.Key)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		// keys with the same key-level policy are evaluated together
+		var chaincodeKeys []string
+		var keyLevel []*common.SignaturePolicyEnvelope
+		keyLevelKeys := make(map[int][]string)
+		for _, key := range uniqueSorted(keys) {
+			policy := keyPolicies(rwset.NameSpace, key)
+			if policy == nil {
+				chaincodeKeys = append(chaincodeKeys, key)
+				continue
+			}
+			index := -1
+			for i, known := range keyLevel {
+				if proto.Equal(known, policy) {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				index = len(keyLevel)
+				keyLevel = append(keyLevel, policy)
+			}
+			keyLevelKeys[index] = append(keyLevelKeys[index], key)
+		}
+
+		if len(chaincodeKeys) > 0 {
+			evaluate(PolicyEvaluation{Source: PolicySourceChaincode, Namespace: rwset.NameSpace, Keys: chaincodeKeys}, chaincodePolicies(rwset.NameSpace))
+		}
+		for i, policy := range keyLevel {
+			evaluate(PolicyEvaluation{Source: PolicySourceKeyLevel, Namespace: rwset.NameSpace, Keys: keyLevelKeys[i]}, &EndorsementPolicy{SignaturePolicy: policy})
+		}
+	}
+
+	if len(coverage.Policies) == 0 {
+		evaluate(PolicyEvaluation{Source: PolicySourceChaincode, Namespace: coverage.Chaincode}, chaincodePolicies(coverage.Chaincode))
+	}
+	return coverage, nil
+}
+
+func uniqueSorted(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,154 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// nOutOfMembers creates signature policy satisfied by members of n out of organizations.
+func nOutOfMembers(t *testing.T, n int32, mspids ...string) *common.SignaturePolicyEnvelope {
+	envelope := &common.SignaturePolicyEnvelope{}
+	var rules []*common.SignaturePolicy
+	for i, mspid := range mspids {
+		role, err := proto.Marshal(&msp.MSPRole{MspIdentifier: mspid, Role: msp.MSPRole_MEMBER})
+		assert.NoError(t, err)
+		envelope.Identities = append(envelope.Identities, &msp.MSPPrincipal{PrincipalClassification: msp.MSPPrincipal_ROLE, Principal: role})
+		rules = append(rules, &common.SignaturePolicy{Type: &common.SignaturePolicy_SignedBy{SignedBy: int32(i)}})
+	}
+	envelope.Rule = &common.SignaturePolicy{Type: &common.SignaturePolicy_NOutOf_{NOutOf: &common.SignaturePolicy_NOutOf{N: n, Rules: rules}}}
+	return envelope
+}
+
+func noKeyPolicies(string, string) *common.SignaturePolicyEnvelope {
+	return nil
+}
+
+func TestEndorsementCoverage(t *testing.T) {
+	deploy, err := GetActionFromBlock("./mock/genesis.pb")
+	assert.NoError(t, err)
+	definition, err := deploy.ChaincodeDefinition()
+	assert.NoError(t, err)
+
+	// fabcar createCar endorsed by Org1 and Org2 peers
+	action, err := GetActionFromBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+
+	policies := func(namespace string) *EndorsementPolicy {
+		if namespace == definition.Name {
+			return definition.ChaincodeEndorsementPolicy()
+		}
+		return nil
+	}
+	coverage, err := action.EndorsementCoverage(policies, noKeyPolicies, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "fabcar", coverage.Chaincode)
+	assert.Equal(t, []string{"Org1MSP", "Org2MSP"}, coverage.EndorsingOrgs)
+	assert.Len(t, coverage.Policies, 1)
+	assert.Equal(t, PolicySourceChaincode, coverage.Policies[0].Source)
+	assert.Equal(t, []string{"CAR11"}, coverage.Policies[0].Keys)
+	assert.True(t, coverage.Satisfied())
+
+	strict := func(string) *EndorsementPolicy {
+		return &EndorsementPolicy{SignaturePolicy: nOutOfMembers(t, 3, "Org1MSP", "Org2MSP", "Org3MSP")}
+	}
+	coverage, err = action.EndorsementCoverage(strict, noKeyPolicies, nil)
+	assert.NoError(t, err)
+	assert.False(t, coverage.Satisfied())
+
+	// key-level policy overrides chaincode policy for the key
+	keyLevel := func(namespace, key string) *common.SignaturePolicyEnvelope {
+		if namespace == "fabcar" && key == "CAR11" {
+			return nOutOfMembers(t, 1, "Org2MSP")
+		}
+		return nil
+	}
+	coverage, err = action.EndorsementCoverage(strict, keyLevel, nil)
+	assert.NoError(t, err)
+	assert.Len(t, coverage.Policies, 1)
+	assert.Equal(t, PolicySourceKeyLevel, coverage.Policies[0].Source)
+	assert.True(t, coverage.Satisfied())
+
+	unknown := func(string) *EndorsementPolicy { return nil }
+	coverage, err = action.EndorsementCoverage(unknown, noKeyPolicies, nil)
+	assert.NoError(t, err)
+	assert.False(t, coverage.Satisfied())
+	assert.Equal(t, "endorsement policy is unknown", coverage.Policies[0].Error)
+
+	// channel config policies can't be evaluated without config
+	channelPolicy := func(string) *EndorsementPolicy {
+		return &EndorsementPolicy{ChannelConfigPolicy: DefaultEndorsementPolicy}
+	}
+	coverage, err = action.EndorsementCoverage(channelPolicy, noKeyPolicies, nil)
+	assert.NoError(t, err)
+	assert.False(t, coverage.Satisfied())
+	assert.NotEmpty(t, coverage.Policies[0].Error)
+}
+
+func TestChaincodeEndorsementPolicy(t *testing.T) {
+	policy := nOutOfMembers(t, 1, "Org1MSP")
+	for _, operation := range []string{LifecycleDeploy, LifecycleUpgrade} {
+		// LSCC definitions have no channel config policy to fall back to
+		definition := &ChaincodeDefinition{Operation: operation, Name: "fabcar", EndorsementPolicy: policy}
+		assert.Equal(t, &EndorsementPolicy{SignaturePolicy: policy}, definition.ChaincodeEndorsementPolicy())
+		definition.EndorsementPolicy = nil
+		assert.Nil(t, definition.ChaincodeEndorsementPolicy())
+	}
+
+	definition := &ChaincodeDefinition{Operation: LifecycleCommit, Name: "fabcar"}
+	assert.Equal(t, &EndorsementPolicy{ChannelConfigPolicy: DefaultEndorsementPolicy}, definition.ChaincodeEndorsementPolicy())
+	definition.ChannelConfigPolicy = "/Channel/Application/Org1Policy"
+	assert.Equal(t, &EndorsementPolicy{ChannelConfigPolicy: "/Channel/Application/Org1Policy"}, definition.ChaincodeEndorsementPolicy())
+}
+
+func TestEndorsementPolicyImplicitMeta(t *testing.T) {
+	txs, err := readTxsFromBlock("./mock/config.pb")
+	assert.NoError(t, err)
+	configEnvelope, err := txs[0].ConfigEnvelope()
+	assert.NoError(t, err)
+
+	action, err := GetActionFromBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	endorsers, err := action.Endorsers()
+	assert.NoError(t, err)
+	assert.Len(t, endorsers, 2)
+
+	// MAJORITY of Org1MSP, Org2MSP and Org3MSP peers
+	policy := &EndorsementPolicy{ChannelConfigPolicy: DefaultEndorsementPolicy}
+	satisfied, err := policy.Evaluate(endorsers, configEnvelope.Config)
+	assert.NoError(t, err)
+	assert.True(t, satisfied)
+
+	satisfied, err = policy.Evaluate(endorsers[:1], configEnvelope.Config)
+	assert.NoError(t, err)
+	assert.False(t, satisfied)
+}
+
+func TestKeyLevelPolicies(t *testing.T) {
+	policy := nOutOfMembers(t, 1, "Org1MSP")
+	policyBytes, err := proto.Marshal(policy)
+	assert.NoError(t, err)
+
+	action := newAction(t, "mycc", [][]byte{[]byte("setPolicy")}, &rwset.TxReadWriteSet{
+		NsRwset: []*rwset.NsReadWriteSet{newNsRWSet(t, "mycc", &kvrwset.KVRWSet{
+			MetadataWrites: []*kvrwset.KVMetadataWrite{
+				{Key: "a", Entries: []*kvrwset.KVMetadataEntry{{Name: ValidationParameterKey, Value: policyBytes}}},
+				{Key: "b"},
+			},
+		})},
+	})
+	policies, err := action.KeyLevelPolicies()
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(policy, policies["mycc"]["a"]))
+	assert.Contains(t, policies["mycc"], "b")
+	assert.Nil(t, policies["mycc"]["b"])
+}
@@ -0,0 +1,141 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Consensus types of the ordering service
+const (
+	ConsensusSolo     = "solo"
+	ConsensusKafka    = "kafka"
+	ConsensusEtcdRaft = "etcdraft"
+	ConsensusBFT      = "smartbft"
+)
+
+// RaftConsenter is a member of the etcdraft cluster of the ordering service.
+type RaftConsenter struct {
+	Host          string
+	Port          uint32
+	ClientTLSCert []byte // pem-encoded
+	ServerTLSCert []byte // pem-encoded
+}
+
+// Endpoint returns host:port of the consenter.
+func (c RaftConsenter) Endpoint() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// OrdererConsensusType extracts consensus type (with consensus-specific metadata) of the ordering service from channel config.
+func OrdererConsensusType(config *common.Config) (*orderer.ConsensusType, error) {
+	if config == nil || config.ChannelGroup == nil {
+		return nil, errors.New("empty channel config")
+	}
+	ordererGroup, ok := config.ChannelGroup.Groups["Orderer"]
+	if !ok {
+		return nil, errors.New("no Orderer group in channel config")
+	}
+	value, ok := ordererGroup.Values["ConsensusType"]
+	if !ok {
+		return nil, errors.New("no ConsensusType in Orderer group")
+	}
+	consensusType := &orderer.ConsensusType{}
+	if err := proto.Unmarshal(value.Value, consensusType); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling ConsensusType")
+	}
+	return consensusType, nil
+}
+
+// RaftConsenters extracts consenters of the etcdraft ordering service from channel config.
+func RaftConsenters(config *common.Config) ([]RaftConsenter, error) {
+	consensusType, err := OrdererConsensusType(config)
+	if err != nil {
+		return nil, err
+	}
+	if consensusType.Type != ConsensusEtcdRaft {
+		return nil, fmt.Errorf("consensus type is %s, not %s", consensusType.Type, ConsensusEtcdRaft)
+	}
+	metadata := &etcdraft.ConfigMetadata{}
+	if err := proto.Unmarshal(consensusType.Metadata, metadata); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling etcdraft ConfigMetadata")
+	}
+
+	var consenters []RaftConsenter
+	for _, consenter := range metadata.Consenters {
+		consenters = append(consenters, RaftConsenter{
+			Host:          consenter.Host,
+			Port:          consenter.Port,
+			ClientTLSCert: consenter.ClientTlsCert,
+			ServerTLSCert: consenter.ServerTlsCert,
+		})
+	}
+	return consenters, nil
+}
+
+// MatchRaftConsenter finds the consenter which owns pem-encoded certificate of the orderer (e.g. the one which signed the block).
+// Raft consenters are defined by their TLS certificates only, so the orderer is matched by:
+// the public key of TLS certificates (if the orderer reuses the key), then by the consenter host among certificate DNS names and common name,
+// then by common name of the TLS certificates.
+func MatchRaftConsenter(consenters []RaftConsenter, cert []byte) (*RaftConsenter, bool) {
+	certificate, err := parseCertificate(cert)
+	if err != nil {
+		return nil, false
+	}
+	tlsCertificates := func(consenter RaftConsenter) []*x509.Certificate {
+		var result []*x509.Certificate
+		for _, tlsCert := range [][]byte{consenter.ServerTLSCert, consenter.ClientTLSCert} {
+			if tlsCertificate, err := parseCertificate(tlsCert); err == nil {
+				result = append(result, tlsCertificate)
+			}
+		}
+		return result
+	}
+
+	matchers := []func(consenter RaftConsenter) bool{
+		func(consenter RaftConsenter) bool {
+			for _, tlsCertificate := range tlsCertificates(consenter) {
+				if bytes.Equal(tlsCertificate.RawSubjectPublicKeyInfo, certificate.RawSubjectPublicKeyInfo) {
+					return true
+				}
+			}
+			return false
+		},
+		func(consenter RaftConsenter) bool {
+			for _, name := range append([]string{certificate.Subject.CommonName}, certificate.DNSNames...) {
+				if strings.EqualFold(name, consenter.Host) {
+					return true
+				}
+			}
+			return false
+		},
+		func(consenter RaftConsenter) bool {
+			for _, tlsCertificate := range tlsCertificates(consenter) {
+				if tlsCertificate.Subject.CommonName != "" && tlsCertificate.Subject.CommonName == certificate.Subject.CommonName {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	for _, match := range matchers {
+		for i := range consenters {
+			if match(consenters[i]) {
+				consenter := consenters[i]
+				return &consenter, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,59 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRaftConsenters(t *testing.T) {
+	block, err := getBlock("./mock/config.pb")
+	assert.NoError(t, err)
+	config, err := ConfigFromBlock(block)
+	assert.NoError(t, err)
+
+	consensusType, err := OrdererConsensusType(config)
+	assert.NoError(t, err)
+	assert.Equal(t, ConsensusEtcdRaft, consensusType.Type)
+
+	consenters, err := RaftConsenters(config)
+	assert.NoError(t, err)
+	assert.Len(t, consenters, 1)
+	assert.Equal(t, "orderer.example.com:7050", consenters[0].Endpoint())
+	tlsIdentity, err := NewIdentity("OrdererMSP", consenters[0].ServerTLSCert)
+	assert.NoError(t, err)
+	assert.Equal(t, "orderer.example.com", tlsIdentity.CommonName)
+	assert.NotEmpty(t, consenters[0].ClientTLSCert)
+
+	// block is signed with the enrollment certificate of the orderer, not TLS one
+	b, err := FromFabricBlock(block)
+	assert.NoError(t, err)
+	consenter, ok := MatchRaftConsenter(consenters, b.OrderersSignatures()[0].Cert)
+	assert.True(t, ok)
+	assert.Equal(t, "orderer.example.com", consenter.Host)
+
+	_, cert, err := tx.Creator()
+	assert.NoError(t, err)
+	_, ok = MatchRaftConsenter(consenters, cert)
+	assert.False(t, ok)
+}
+
+func TestRaftConsentersNotRaft(t *testing.T) {
+	consensusType, err := proto.Marshal(&orderer.ConsensusType{Type: ConsensusSolo})
+	assert.NoError(t, err)
+	config := &common.Config{ChannelGroup: &common.ConfigGroup{Groups: map[string]*common.ConfigGroup{
+		"Orderer": {Values: map[string]*common.ConfigValue{"ConsensusType": {Value: consensusType}}},
+	}}}
+	_, err = RaftConsenters(config)
+	assert.EqualError(t, err, "consensus type is solo, not etcdraft")
+
+	_, err = RaftConsenters(&common.Config{ChannelGroup: &common.ConfigGroup{}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// Roles of the identities defined by Fabric NodeOUs
+const (
+	RoleClient  = "client"
+	RolePeer    = "peer"
+	RoleAdmin   = "admin"
+	RoleOrderer = "orderer"
+)
+
+// Identity contains information about x509 identity of the transaction creator, endorser or orderer.
+type Identity struct {
+	MSPID               string
+	Cert                []byte // pem-encoded
+	Fingerprint         string // hex-encoded SHA256 hash of the DER-encoded certificate
+	Subject             string
+	CommonName          string
+	OrganizationalUnits []string
+	Roles               []string // roles derived from OUs (client, peer, admin, orderer)
+	Issuer              string
+	IssuerCommonName    string
+	DNSNames            []string
+	EmailAddresses      []string
+	IPAddresses         []string
+	URIs                []string
+	SerialNumber        string // hex-encoded
+	NotBefore           time.Time
+	NotAfter            time.Time
+	SubjectKeyId        []byte
+	AuthorityKeyId      []byte
+}
+
+// NewIdentity parses pem-encoded x509 certificate of the member of MSP with ID 'mspid'.
+func NewIdentity(mspid string, cert []byte) (*Identity, error) {
+	certificate, err := parseCertificate(cert)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse certificate of %s member", mspid)
+	}
+	return identityFromCertificate(mspid, cert, certificate), nil
+}
+
+// IdentityFromSerialized parses marshaled msp.SerializedIdentity (e.g. creator from common.SignatureHeader or peer.Endorsement.Endorser).
+func IdentityFromSerialized(serialized []byte) (*Identity, error) {
+	identity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serialized, identity); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling SerializedIdentity")
+	}
+	return NewIdentity(identity.Mspid, identity.IdBytes)
+}
+
+func identityFromCertificate(mspid string, pem []byte, cert *x509.Certificate) *Identity {
+	fingerprint := sha256.Sum256(cert.Raw)
+	identity := &Identity{
+		MSPID:               mspid,
+		Cert:                pem,
+		Fingerprint:         hex.EncodeToString(fingerprint[:]),
+		Subject:             cert.Subject.String(),
+		CommonName:          cert.Subject.CommonName,
+		OrganizationalUnits: cert.Subject.OrganizationalUnit,
+		Issuer:              cert.Issuer.String(),
+		IssuerCommonName:    cert.Issuer.CommonName,
+		DNSNames:            cert.DNSNames,
+		EmailAddresses:      cert.EmailAddresses,
+		SerialNumber:        cert.SerialNumber.Text(16),
+		NotBefore:           cert.NotBefore,
+		NotAfter:            cert.NotAfter,
+		SubjectKeyId:        cert.SubjectKeyId,
+		AuthorityKeyId:      cert.AuthorityKeyId,
+	}
+	for _, ip := range cert.IPAddresses {
+		identity.IPAddresses = append(identity.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		switch role := strings.ToLower(ou); role {
+		case RoleClient, RolePeer, RoleAdmin, RoleOrderer:
+			identity.Roles = append(identity.Roles, role)
+		}
+	}
+	return identity
+}
+
+// HasRole checks whether the identity has the role (according to its OUs).
+func (i *Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidAt checks whether the certificate is within its validity window at the moment.
+func (i *Identity) IsValidAt(moment time.Time) bool {
+	return !moment.Before(i.NotBefore) && !moment.After(i.NotAfter)
+}
+
+// Identity returns identity of the orderer which made the signature.
+func (s BlockSignature) Identity() (*Identity, error) {
+	return NewIdentity(s.MSPID, s.Cert)
+}
+
+// CreatorIdentity returns identity of the transaction creator.
+func (tx *Tx) CreatorIdentity() (*Identity, error) {
+	mspid, cert, err := tx.Creator()
+	if err != nil {
+		return nil, err
+	}
+	return NewIdentity(mspid, cert)
+}
+
+// CreatorIdentity returns identity of the action creator.
+func (a *Action) CreatorIdentity() (*Identity, error) {
+	return IdentityFromSerialized(a.SignatureHeader.Creator)
+}
+
+// EndorserIdentities returns identities of all endorsers of the action.
+func (a *Action) EndorserIdentities() ([]*Identity, error) {
+	var identities []*Identity
+	for _, endorsement := range a.Endorsements() {
+		identity, err := IdentityFromSerialized(endorsement.Endorser)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
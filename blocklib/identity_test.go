@@ -0,0 +1,71 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreatorIdentity(t *testing.T) {
+	identity, err := tx.CreatorIdentity()
+	assert.NoError(t, err)
+	assert.Equal(t, "Org1MSP", identity.MSPID)
+	assert.Equal(t, "22cac6f62054764d8974a4563904e535d13738b02a9f5ffbb5f34e7022dc9ab2", identity.Fingerprint)
+	assert.Equal(t, "user1", identity.CommonName)
+	assert.Equal(t, []string{"client"}, identity.OrganizationalUnits)
+	assert.Equal(t, []string{RoleClient}, identity.Roles)
+	assert.True(t, identity.HasRole(RoleClient))
+	assert.False(t, identity.HasRole(RoleAdmin))
+	assert.Equal(t, "ca.org1.example.com", identity.IssuerCommonName)
+	assert.Equal(t, "CN=ca.org1.example.com,O=org1.example.com,L=Durham,ST=North Carolina,C=US", identity.Issuer)
+	assert.Equal(t, "1781ebffd6dfa7fab6a953d023efbb2fe1244f9", identity.SerialNumber)
+	assert.Equal(t, time.Date(2020, 10, 25, 20, 57, 0, 0, time.UTC), identity.NotBefore)
+	assert.Equal(t, time.Date(2021, 10, 25, 21, 2, 0, 0, time.UTC), identity.NotAfter)
+	assert.Len(t, identity.SubjectKeyId, 20)
+	assert.True(t, identity.IsValidAt(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, identity.IsValidAt(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	actions, err := tx.Actions()
+	assert.NoError(t, err)
+	actionCreator, err := actions[0].CreatorIdentity()
+	assert.NoError(t, err)
+	assert.Equal(t, identity.Fingerprint, actionCreator.Fingerprint)
+}
+
+func TestEndorserIdentities(t *testing.T) {
+	action, err := GetActionFromBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	identities, err := action.EndorserIdentities()
+	assert.NoError(t, err)
+	assert.Len(t, identities, 2)
+
+	assert.Equal(t, "Org1MSP", identities[0].MSPID)
+	assert.Equal(t, []string{RolePeer}, identities[0].Roles)
+	assert.Equal(t, []string{"peer0.org1.example.com"}, identities[0].DNSNames)
+	assert.Equal(t, "Org2MSP", identities[1].MSPID)
+	assert.Equal(t, "ca.org2.example.com", identities[1].IssuerCommonName)
+}
+
+func TestOrdererIdentity(t *testing.T) {
+	fabBlock, err := getBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	block, err := FromFabricBlock(fabBlock)
+	assert.NoError(t, err)
+
+	identity, err := block.OrderersSignatures()[0].Identity()
+	assert.NoError(t, err)
+	assert.Equal(t, "OrdererMSP", identity.MSPID)
+	assert.Equal(t, "orderer", identity.CommonName)
+	assert.Equal(t, []string{RoleOrderer}, identity.Roles)
+	assert.Equal(t, []string{"orderer.example.com", "localhost"}, identity.DNSNames)
+}
+
+func TestNewIdentityInvalidCert(t *testing.T) {
+	_, err := NewIdentity("Org1MSP", []byte("not a certificate"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/common"
+)
+
+// Types of tamper indicators
+const (
+	TamperTxId         = "txid"          // TxID is not equal to the hash of the nonce and creator
+	TamperProposalHash = "proposal_hash" // ProposalHash doesn't match the proposal header and payload of the transaction
+	TamperMalformed    = "malformed"     // transaction can't be decoded for the checks
+)
+
+// TamperIndicator is a mismatch between the transaction fields which must be consistent in an untampered transaction.
+type TamperIndicator struct {
+	Type        string
+	TxIndex     int
+	ActionIndex int // -1 for transaction-level indicators
+	TxId        string
+	Expected    string // hex-encoded
+	Actual      string // hex-encoded (TxID as is)
+	Error       string
+}
+
+// ComputeTxId computes transaction ID the way Fabric does: hex-encoded SHA256 hash of the nonce concatenated with the serialized creator.
+func ComputeTxId(nonce, creator []byte) string {
+	hash := sha256.Sum256(concat(nonce, creator))
+	return hex.EncodeToString(hash[:])
+}
+
+// ComputeProposalHash computes hash of the proposal the way Fabric does for committed transactions:
+// SHA256 hash of the channel header, signature header and the chaincode proposal payload (without transient data).
+func ComputeProposalHash(header *common.Header, chaincodeProposalPayload []byte) []byte {
+	hash := sha256.Sum256(concat(header.ChannelHeader, header.SignatureHeader, chaincodeProposalPayload))
+	return hash[:]
+}
+
+// CheckIntegrity checks that TxID of the transaction is the hash of its nonce and creator
+// and that proposal hashes of endorser transaction actions match the proposal header and payload.
+// The returned indicators have TxIndex set to 0.
+func (tx *Tx) CheckIntegrity() []TamperIndicator {
+	malformed := func(err error) []TamperIndicator {
+		return []TamperIndicator{{Type: TamperMalformed, ActionIndex: -1, Error: err.Error()}}
+	}
+	payload, err := tx.Payload()
+	if err != nil {
+		return malformed(err)
+	}
+	if payload.Header == nil {
+		return malformed(fmt.Errorf("no header in transaction payload"))
+	}
+	header, err := tx.ChannelHeader()
+	if err != nil {
+		return malformed(err)
+	}
+	sighdr, err := tx.SignatureHeader()
+	if err != nil {
+		return malformed(err)
+	}
+
+	var indicators []TamperIndicator
+	// config transactions are created by orderers without TxID
+	if header.TxId != "" {
+		if expected := ComputeTxId(sighdr.Nonce, sighdr.Creator); expected != header.TxId {
+			indicators = append(indicators, TamperIndicator{
+				Type:        TamperTxId,
+				ActionIndex: -1,
+				TxId:        header.TxId,
+				Expected:    expected,
+				Actual:      header.TxId,
+			})
+		}
+	}
+
+	if common.HeaderType(header.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return indicators
+	}
+	actions, err := tx.Actions()
+	if err != nil {
+		return append(indicators, malformed(err)...)
+	}
+	for i, action := range actions {
+		actual, err := action.ProposalHash()
+		if err != nil {
+			indicators = append(indicators, TamperIndicator{Type: TamperMalformed, ActionIndex: i, TxId: header.TxId, Error: err.Error()})
+			continue
+		}
+		expected := ComputeProposalHash(payload.Header, action.Payload.ChaincodeProposalPayload)
+		if !bytes.Equal(expected, actual) {
+			indicators = append(indicators, TamperIndicator{
+				Type:        TamperProposalHash,
+				ActionIndex: i,
+				TxId:        header.TxId,
+				Expected:    hex.EncodeToString(expected),
+				Actual:      hex.EncodeToString(actual),
+			})
+		}
+	}
+	return indicators
+}
@@ -0,0 +1,96 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTxCheckIntegrity(t *testing.T) {
+	for _, path := range []string{"./mock/sampleblock.pb", "./mock/mvcc_read_conflict.pb", "./mock/withevents.pb", "./mock/genesis.pb", "./mock/config.pb"} {
+		txs, err := readTxsFromBlock(path)
+		assert.NoError(t, err)
+		for _, tx := range txs {
+			assert.Empty(t, tx.CheckIntegrity(), path)
+		}
+	}
+}
+
+// tamperTx re-marshals the transaction after modification of its payload, channel header and the first action.
+func tamperTx(t *testing.T, tx Tx, modify func(payload *common.Payload, header *common.ChannelHeader, action *peer.TransactionAction)) Tx {
+	envelope, err := tx.Envelope()
+	assert.NoError(t, err)
+	payload, err := tx.Payload()
+	assert.NoError(t, err)
+	header, err := tx.ChannelHeader()
+	assert.NoError(t, err)
+	transaction, err := tx.PeerTransaction()
+	assert.NoError(t, err)
+
+	modify(payload, header, transaction.Actions[0])
+
+	payload.Header.ChannelHeader, err = proto.Marshal(header)
+	assert.NoError(t, err)
+	payload.Data, err = proto.Marshal(transaction)
+	assert.NoError(t, err)
+	envelope.Payload, err = proto.Marshal(payload)
+	assert.NoError(t, err)
+	tx.Data, err = proto.Marshal(envelope)
+	assert.NoError(t, err)
+	return tx
+}
+
+func TestTxCheckIntegrityTampered(t *testing.T) {
+	// replaced TxID breaks both TxID and proposal hash (channel header is a part of the proposal)
+	tampered := tamperTx(t, tx, func(_ *common.Payload, header *common.ChannelHeader, _ *peer.TransactionAction) {
+		header.TxId = "0000000000000000000000000000000000000000000000000000000000000000"
+	})
+	indicators := tampered.CheckIntegrity()
+	assert.Len(t, indicators, 2)
+	assert.Equal(t, TamperTxId, indicators[0].Type)
+	assert.Equal(t, -1, indicators[0].ActionIndex)
+	txID, err := tx.TxId()
+	assert.NoError(t, err)
+	assert.Equal(t, txID, indicators[0].Expected)
+	assert.Equal(t, TamperProposalHash, indicators[1].Type)
+	assert.Equal(t, 0, indicators[1].ActionIndex)
+
+	// replaced chaincode input breaks proposal hash only
+	tampered = tamperTx(t, tx, func(_ *common.Payload, _ *common.ChannelHeader, action *peer.TransactionAction) {
+		ccActionPayload := &peer.ChaincodeActionPayload{}
+		assert.NoError(t, proto.Unmarshal(action.Payload, ccActionPayload))
+		ccActionPayload.ChaincodeProposalPayload = append(ccActionPayload.ChaincodeProposalPayload, 0)
+		var err error
+		action.Payload, err = proto.Marshal(ccActionPayload)
+		assert.NoError(t, err)
+	})
+	indicators = tampered.CheckIntegrity()
+	assert.Len(t, indicators, 1)
+	assert.Equal(t, TamperProposalHash, indicators[0].Type)
+	assert.NotEqual(t, indicators[0].Expected, indicators[0].Actual)
+}
+
+func TestVerifyBlockTamperIndicators(t *testing.T) {
+	block, err := getBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	report, err := VerifyBlock(block)
+	assert.NoError(t, err)
+	assert.Empty(t, report.TamperIndicators)
+
+	tampered := tamperTx(t, tx, func(_ *common.Payload, header *common.ChannelHeader, _ *peer.TransactionAction) {
+		header.TxId = "tampered"
+	})
+	block.Data.Data[0] = tampered.Data
+	report, err = VerifyBlock(block)
+	assert.NoError(t, err)
+	assert.False(t, report.Valid())
+	assert.Len(t, report.TamperIndicators, 2)
+	assert.Equal(t, "tampered", report.TamperIndicators[0].TxId)
+}
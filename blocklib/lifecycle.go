@@ -0,0 +1,249 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Namespaces of the system chaincodes responsible for chaincode lifecycle
+const (
+	LSCCNamespace      = "lscc"
+	LifecycleNamespace = "_lifecycle"
+)
+
+// Chaincode lifecycle operations
+const (
+	LifecycleDeploy  = "deploy"  // legacy LSCC deploy
+	LifecycleUpgrade = "upgrade" // legacy LSCC upgrade
+	LifecycleApprove = "approve" // _lifecycle ApproveChaincodeDefinitionForMyOrg
+	LifecycleCommit  = "commit"  // _lifecycle CommitChaincodeDefinition
+)
+
+const (
+	approveFunction      = "ApproveChaincodeDefinitionForMyOrg"
+	commitFunction       = "CommitChaincodeDefinition"
+	implicitOrgPrefix    = "_implicit_org_"
+	lifecycleMetadataKey = "namespaces/metadata/"
+)
+
+// ChaincodeDefinition contains chaincode parameters set by lifecycle operation.
+type ChaincodeDefinition struct {
+	Operation           string
+	Name                string
+	Version             string
+	Sequence            int64                           // _lifecycle only
+	EndorsementPolicy   *common.SignaturePolicyEnvelope // nil if default policy or channel config policy is used
+	ChannelConfigPolicy string                          // reference to the channel config policy, e.g. /Channel/Application/Endorsement (_lifecycle only)
+	EndorsementPlugin   string                          // escc for LSCC
+	ValidationPlugin    string                          // vscc for LSCC
+	Collections         *peer.CollectionConfigPackage
+	InitRequired        bool
+	Approvals           []string // MSP IDs of the organizations which approved the definition (approve operation only)
+}
+
+// ChaincodeDefinition decodes chaincode definition from the LSCC deploy/upgrade or _lifecycle approve/commit action.
+// It returns nil (without error) if the action is not a lifecycle operation or did not write the definition to the ledger.
+func (a *Action) ChaincodeDefinition() (*ChaincodeDefinition, error) {
+	ccAction, err := a.ChaincodeAction()
+	if err != nil {
+		return nil, err
+	}
+	if ccAction.ChaincodeId == nil {
+		return nil, nil
+	}
+
+	switch ccAction.ChaincodeId.Name {
+	case LSCCNamespace:
+		return a.lsccDefinition()
+	case LifecycleNamespace:
+		return a.lifecycleDefinition()
+	}
+	return nil, nil
+}
+
+// lsccDefinition decodes args of the legacy LSCC deploy/upgrade: function, channel, ChaincodeDeploymentSpec, policy, escc, vscc, collections.
+func (a *Action) lsccDefinition() (*ChaincodeDefinition, error) {
+	args, err := a.ChaincodeInput()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 3 || (args[0] != LifecycleDeploy && args[0] != LifecycleUpgrade) {
+		return nil, nil
+	}
+
+	cds := &peer.ChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal([]byte(args[2]), cds); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling ChaincodeDeploymentSpec")
+	}
+	if cds.ChaincodeSpec == nil || cds.ChaincodeSpec.ChaincodeId == nil {
+		return nil, errors.New("no chaincode ID in ChaincodeDeploymentSpec")
+	}
+
+	definition := &ChaincodeDefinition{
+		Operation: args[0],
+		Name:      cds.ChaincodeSpec.ChaincodeId.Name,
+		Version:   cds.ChaincodeSpec.ChaincodeId.Version,
+	}
+
+	written, err := a.writesKey(LSCCNamespace, definition.Name)
+	if err != nil || !written {
+		return nil, err
+	}
+
+	if len(args) > 3 && len(args[3]) > 0 {
+		definition.EndorsementPolicy = &common.SignaturePolicyEnvelope{}
+		if err := proto.Unmarshal([]byte(args[3]), definition.EndorsementPolicy); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling endorsement policy")
+		}
+	}
+	if len(args) > 4 {
+		definition.EndorsementPlugin = args[4]
+	}
+	if len(args) > 5 {
+		definition.ValidationPlugin = args[5]
+	}
+	if len(args) > 6 && len(args[6]) > 0 {
+		definition.Collections = &peer.CollectionConfigPackage{}
+		if err := proto.Unmarshal([]byte(args[6]), definition.Collections); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling collections config")
+		}
+	}
+	return definition, nil
+}
+
+// lifecycleDefinition decodes args of the _lifecycle ApproveChaincodeDefinitionForMyOrg and CommitChaincodeDefinition.
+func (a *Action) lifecycleDefinition() (*ChaincodeDefinition, error) {
+	args, err := a.ChaincodeInput()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 2 {
+		return nil, nil
+	}
+
+	var definition *ChaincodeDefinition
+	switch args[0] {
+	case approveFunction:
+		approveArgs := &lifecycle.ApproveChaincodeDefinitionForMyOrgArgs{}
+		if err := proto.Unmarshal([]byte(args[1]), approveArgs); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling ApproveChaincodeDefinitionForMyOrgArgs")
+		}
+		definition = &ChaincodeDefinition{
+			Operation:         LifecycleApprove,
+			Name:              approveArgs.Name,
+			Version:           approveArgs.Version,
+			Sequence:          approveArgs.Sequence,
+			EndorsementPlugin: approveArgs.EndorsementPlugin,
+			ValidationPlugin:  approveArgs.ValidationPlugin,
+			Collections:       approveArgs.Collections,
+			InitRequired:      approveArgs.InitRequired,
+		}
+		// approval is written to the implicit private collection of the approving organization
+		definition.Approvals, err = a.implicitCollectionOrgs()
+		if err != nil || len(definition.Approvals) == 0 {
+			return nil, err
+		}
+		err = definition.setValidationParameter(approveArgs.ValidationParameter)
+	case commitFunction:
+		commitArgs := &lifecycle.CommitChaincodeDefinitionArgs{}
+		if err := proto.Unmarshal([]byte(args[1]), commitArgs); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling CommitChaincodeDefinitionArgs")
+		}
+		definition = &ChaincodeDefinition{
+			Operation:         LifecycleCommit,
+			Name:              commitArgs.Name,
+			Version:           commitArgs.Version,
+			Sequence:          commitArgs.Sequence,
+			EndorsementPlugin: commitArgs.EndorsementPlugin,
+			ValidationPlugin:  commitArgs.ValidationPlugin,
+			Collections:       commitArgs.Collections,
+			InitRequired:      commitArgs.InitRequired,
+		}
+		var written bool
+		written, err = a.writesKey(LifecycleNamespace, lifecycleMetadataKey+definition.Name)
+		if err != nil || !written {
+			return nil, err
+		}
+		err = definition.setValidationParameter(commitArgs.ValidationParameter)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// setValidationParameter decodes peer.ApplicationPolicy into signature policy or channel config policy reference.
+func (d *ChaincodeDefinition) setValidationParameter(validationParameter []byte) error {
+	if len(validationParameter) == 0 {
+		return nil
+	}
+	policy := &peer.ApplicationPolicy{}
+	if err := proto.Unmarshal(validationParameter, policy); err != nil {
+		return errors.Wrap(err, "error unmarshaling ApplicationPolicy")
+	}
+	switch t := policy.Type.(type) {
+	case *peer.ApplicationPolicy_SignaturePolicy:
+		d.EndorsementPolicy = t.SignaturePolicy
+	case *peer.ApplicationPolicy_ChannelConfigPolicyReference:
+		d.ChannelConfigPolicy = t.ChannelConfigPolicyReference
+	}
+	return nil
+}
+
+// writesKey checks whether the action writes the key to the namespace.
+func (a *Action) writesKey(namespace, key string) (bool, error) {
+	rwsets, err := a.RWSets()
+	if err != nil {
+		return false, err
+	}
+	for _, rwset := range rwsets {
+		if rwset.NameSpace != namespace {
+			continue
+		}
+		for _, write := range rwset.KVRWSet.Writes {
+			if write.Key == key {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// implicitCollectionOrgs returns MSP IDs of the organizations whose implicit private collections of _lifecycle are written by the action.
+func (a *Action) implicitCollectionOrgs() ([]string, error) {
+	rwsets, err := a.RWSets()
+	if err != nil {
+		return nil, err
+	}
+	var orgs []string
+	for _, rwset := range rwsets {
+		if rwset.NameSpace != LifecycleNamespace {
+			continue
+		}
+		for _, collection := range rwset.CollectionHashedReadWriteSet {
+			if !strings.HasPrefix(collection.CollectionName, implicitOrgPrefix) {
+				continue
+			}
+			hashedRWSet := &kvrwset.HashedRWSet{}
+			if err := proto.Unmarshal(collection.HashedRwset, hashedRWSet); err != nil {
+				return nil, err
+			}
+			if len(hashedRWSet.HashedWrites) > 0 {
+				orgs = append(orgs, strings.TrimPrefix(collection.CollectionName, implicitOrgPrefix))
+			}
+		}
+	}
+	return orgs, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newAction builds an action invoking chaincode 'namespace' with 'args' and producing 'results'.
+func newAction(t *testing.T, namespace string, args [][]byte, results *rwset.TxReadWriteSet) *Action {
+	chaincodeID := &peer.ChaincodeID{Name: namespace}
+	input, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{ChaincodeId: chaincodeID, Input: &peer.ChaincodeInput{Args: args}},
+	})
+	assert.NoError(t, err)
+	proposalPayload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: input})
+	assert.NoError(t, err)
+	resultsBytes, err := proto.Marshal(results)
+	assert.NoError(t, err)
+	extension, err := proto.Marshal(&peer.ChaincodeAction{Results: resultsBytes, ChaincodeId: chaincodeID})
+	assert.NoError(t, err)
+	responsePayload, err := proto.Marshal(&peer.ProposalResponsePayload{Extension: extension})
+	assert.NoError(t, err)
+
+	return &Action{
+		Payload: &peer.ChaincodeActionPayload{
+			ChaincodeProposalPayload: proposalPayload,
+			Action:                   &peer.ChaincodeEndorsedAction{ProposalResponsePayload: responsePayload},
+		},
+		SignatureHeader: &common.SignatureHeader{},
+	}
+}
+
+func newNsRWSet(t *testing.T, namespace string, kvrwset *kvrwset.KVRWSet, collections ...*rwset.CollectionHashedReadWriteSet) *rwset.NsReadWriteSet {
+	rw, err := proto.Marshal(kvrwset)
+	assert.NoError(t, err)
+	return &rwset.NsReadWriteSet{Namespace: namespace, Rwset: rw, CollectionHashedRwset: collections}
+}
+
+func TestChaincodeDefinitionLSCC(t *testing.T) {
+	action, err := GetActionFromBlock("./mock/genesis.pb")
+	assert.NoError(t, err)
+
+	definition, err := action.ChaincodeDefinition()
+	assert.NoError(t, err)
+	assert.Equal(t, LifecycleDeploy, definition.Operation)
+	assert.Equal(t, "fabcar", definition.Name)
+	assert.Equal(t, "1.0", definition.Version)
+	assert.Equal(t, "escc", definition.EndorsementPlugin)
+	assert.Equal(t, "vscc", definition.ValidationPlugin)
+	assert.Len(t, definition.EndorsementPolicy.Identities, 2)
+	assert.Nil(t, definition.Collections)
+}
+
+func TestChaincodeDefinitionNotLifecycle(t *testing.T) {
+	action, err := GetActionFromBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+
+	definition, err := action.ChaincodeDefinition()
+	assert.NoError(t, err)
+	assert.Nil(t, definition)
+}
+
+func TestChaincodeDefinitionLifecycle(t *testing.T) {
+	policy, err := proto.Marshal(&peer.ApplicationPolicy{
+		Type: &peer.ApplicationPolicy_ChannelConfigPolicyReference{ChannelConfigPolicyReference: "/Channel/Application/Endorsement"},
+	})
+	assert.NoError(t, err)
+
+	t.Run("approve", func(t *testing.T) {
+		approveArgs, err := proto.Marshal(&lifecycle.ApproveChaincodeDefinitionForMyOrgArgs{
+			Name: "fabcar", Version: "2.0", Sequence: 2, ValidationParameter: policy, InitRequired: true,
+		})
+		assert.NoError(t, err)
+		hashedRWSet, err := proto.Marshal(&kvrwset.HashedRWSet{HashedWrites: []*kvrwset.KVWriteHash{{KeyHash: []byte("hash")}}})
+		assert.NoError(t, err)
+
+		action := newAction(t, LifecycleNamespace, [][]byte{[]byte(approveFunction), approveArgs}, &rwset.TxReadWriteSet{
+			NsRwset: []*rwset.NsReadWriteSet{
+				newNsRWSet(t, LifecycleNamespace, &kvrwset.KVRWSet{}, &rwset.CollectionHashedReadWriteSet{
+					CollectionName: "_implicit_org_Org1MSP",
+					HashedRwset:    hashedRWSet,
+				}),
+			},
+		})
+
+		definition, err := action.ChaincodeDefinition()
+		assert.NoError(t, err)
+		assert.Equal(t, LifecycleApprove, definition.Operation)
+		assert.Equal(t, int64(2), definition.Sequence)
+		assert.Equal(t, "/Channel/Application/Endorsement", definition.ChannelConfigPolicy)
+		assert.True(t, definition.InitRequired)
+		assert.Equal(t, []string{"Org1MSP"}, definition.Approvals)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		signaturePolicy, err := proto.Marshal(&peer.ApplicationPolicy{
+			Type: &peer.ApplicationPolicy_SignaturePolicy{SignaturePolicy: &common.SignaturePolicyEnvelope{
+				Rule:       &common.SignaturePolicy{Type: &common.SignaturePolicy_SignedBy{SignedBy: 0}},
+				Identities: []*msp.MSPPrincipal{{}},
+			}},
+		})
+		assert.NoError(t, err)
+		commitArgs, err := proto.Marshal(&lifecycle.CommitChaincodeDefinitionArgs{
+			Name: "fabcar", Version: "2.0", Sequence: 2, ValidationParameter: signaturePolicy,
+		})
+		assert.NoError(t, err)
+
+		action := newAction(t, LifecycleNamespace, [][]byte{[]byte(commitFunction), commitArgs}, &rwset.TxReadWriteSet{
+			NsRwset: []*rwset.NsReadWriteSet{
+				newNsRWSet(t, LifecycleNamespace, &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: "namespaces/metadata/fabcar"}}}),
+			},
+		})
+
+		definition, err := action.ChaincodeDefinition()
+		assert.NoError(t, err)
+		assert.Equal(t, LifecycleCommit, definition.Operation)
+		assert.Equal(t, "fabcar", definition.Name)
+		assert.Equal(t, "2.0", definition.Version)
+		assert.Empty(t, definition.ChannelConfigPolicy)
+		assert.NotNil(t, definition.EndorsementPolicy)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		action := newAction(t, LifecycleNamespace, [][]byte{[]byte("QueryChaincodeDefinition"), nil}, &rwset.TxReadWriteSet{})
+		definition, err := action.ChaincodeDefinition()
+		assert.NoError(t, err)
+		assert.Nil(t, definition)
+	})
+}
@@ -0,0 +1,223 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// CertificateCheck is a result of the validation of the signer certificate against its MSP.
+type CertificateCheck struct {
+	Type        string // signer kind: SignatureBlock (orderer), SignatureCreator or SignatureEndorsement
+	TxIndex     int    // -1 for orderers
+	ActionIndex int    // -1 for orderers and creators
+	Index       int    // number of the signature in block metadata or among action endorsements
+	TxId        string
+	MSPID       string
+	Cert        []byte // pem-encoded
+	Valid       bool
+	Revoked     bool
+	Error       string // reason of the failure
+}
+
+// MSPValidator validates x509 certificates of signers against root and intermediate certificates
+// and revocation lists of the MSPs defined in the channel config.
+type MSPValidator struct {
+	msps map[string]*mspTrust
+}
+
+type mspTrust struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	crls          []*pkix.CertificateList
+}
+
+// NewMSPValidator creates MSPValidator from the MSPs (application and orderer organizations) of channel config.
+func NewMSPValidator(config *common.Config) (*MSPValidator, error) {
+	msps, err := MSPConfigs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	validator := &MSPValidator{msps: make(map[string]*mspTrust)}
+	for mspid, mspConfig := range msps {
+		trust := &mspTrust{roots: x509.NewCertPool(), intermediates: x509.NewCertPool()}
+		for _, cert := range mspConfig.RootCerts {
+			certificate, err := parseCertificate(cert)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse root certificate of %s", mspid)
+			}
+			trust.roots.AddCert(certificate)
+		}
+		for _, cert := range mspConfig.IntermediateCerts {
+			certificate, err := parseCertificate(cert)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse intermediate certificate of %s", mspid)
+			}
+			trust.intermediates.AddCert(certificate)
+		}
+		for _, crl := range mspConfig.RevocationList {
+			list, err := x509.ParseCRL(crl)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse revocation list of %s", mspid)
+			}
+			trust.crls = append(trust.crls, list)
+		}
+		validator.msps[mspid] = trust
+	}
+	return validator, nil
+}
+
+// ValidateCertificate checks that pem-encoded certificate chains up to the root certificates of MSP with ID 'mspid'
+// (possibly through its intermediate certificates), was valid at the moment and is not revoked by the MSP revocation lists.
+func (v *MSPValidator) ValidateCertificate(mspid string, cert []byte, moment time.Time) CertificateCheck {
+	check := CertificateCheck{MSPID: mspid, Cert: cert}
+	trust, ok := v.msps[mspid]
+	if !ok {
+		check.Error = fmt.Sprintf("MSP %s is not defined in channel config", mspid)
+		return check
+	}
+	certificate, err := parseCertificate(cert)
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to parse certificate: %s", err)
+		return check
+	}
+
+	chains, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         trust.roots,
+		Intermediates: trust.intermediates,
+		CurrentTime:   moment,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	// the first chain is enough, the issuer of the certificate is the same in all the chains
+	if len(chains[0]) > 1 && trust.isRevoked(certificate, chains[0][1]) {
+		check.Revoked = true
+		check.Error = fmt.Sprintf("certificate with serial number %s is revoked", certificate.SerialNumber.Text(16))
+		return check
+	}
+	check.Valid = true
+	return check
+}
+
+// isRevoked checks whether any revocation list signed by the certificate issuer contains the certificate.
+func (t *mspTrust) isRevoked(certificate, issuer *x509.Certificate) bool {
+	for _, crl := range t.crls {
+		if err := issuer.CheckCRLSignature(crl); err != nil {
+			continue
+		}
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			if revoked.SerialNumber.Cmp(certificate.SerialNumber) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateBlock validates certificates of the orderers which signed the block, creators of all its transactions
+// and endorsers of all actions of endorser transactions. Certificates are validated at the moment of the transaction
+// (orderer certificates at the moment of the first transaction of the block).
+// The validator must be created from the config in force for the block (see Block.LastConfig).
+func (v *MSPValidator) ValidateBlock(block *common.Block) ([]CertificateCheck, error) {
+	b, err := FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []CertificateCheck
+	var blockTime time.Time
+	if len(txs) > 0 {
+		if blockTime, err = txs[0].Timestamp(); err != nil {
+			return nil, err
+		}
+	}
+	for i, signature := range b.OrderersSignatures() {
+		check := CertificateCheck{MSPID: signature.MSPID, Error: "no orderer certificate in the block"}
+		if len(signature.Cert) > 0 {
+			check = v.ValidateCertificate(signature.MSPID, signature.Cert, blockTime)
+		}
+		check.Type, check.TxIndex, check.ActionIndex, check.Index = SignatureBlock, -1, -1, i
+		checks = append(checks, check)
+	}
+
+	for txIndex := range txs {
+		tx := &txs[txIndex]
+		header, err := tx.ChannelHeader()
+		if err != nil {
+			return nil, err
+		}
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			return nil, err
+		}
+
+		mspid, cert, err := tx.Creator()
+		if err != nil {
+			return nil, err
+		}
+		check := v.ValidateCertificate(mspid, cert, timestamp)
+		check.Type, check.TxIndex, check.ActionIndex, check.TxId = SignatureCreator, txIndex, -1, header.TxId
+		checks = append(checks, check)
+
+		if common.HeaderType(header.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+			continue
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			return nil, err
+		}
+		for actionIndex := range actions {
+			for i, endorsement := range actions[actionIndex].Endorsements() {
+				var check CertificateCheck
+				endorser := &msp.SerializedIdentity{}
+				if err := proto.Unmarshal(endorsement.Endorser, endorser); err != nil {
+					check.Error = fmt.Sprintf("error unmarshaling SerializedIdentity: %s", err)
+				} else {
+					check = v.ValidateCertificate(endorser.Mspid, endorser.IdBytes, timestamp)
+				}
+				check.Type, check.TxIndex, check.ActionIndex, check.Index, check.TxId = SignatureEndorsement, txIndex, actionIndex, i, header.TxId
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks, nil
+}
+
+// ConfigFromBlock extracts channel config from the configuration block.
+func ConfigFromBlock(block *common.Block) (*common.Config, error) {
+	b, err := FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsConfig() {
+		return nil, fmt.Errorf("block %d is not a configuration block", b.Number())
+	}
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+	configEnvelope, err := txs[0].ConfigEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	return configEnvelope.Config, nil
+}
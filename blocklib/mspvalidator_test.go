@@ -0,0 +1,144 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestMSPValidatorValidateBlock(t *testing.T) {
+	configBlock, err := getBlock("./mock/forIntegrityCheck.pb")
+	assert.NoError(t, err)
+	config, err := ConfigFromBlock(configBlock)
+	assert.NoError(t, err)
+	validator, err := NewMSPValidator(config)
+	assert.NoError(t, err)
+
+	// block 2 is validated against the config of block 1
+	block, err := getBlock("./mock/configUpdate.pb")
+	assert.NoError(t, err)
+	checks, err := validator.ValidateBlock(block)
+	assert.NoError(t, err)
+	assert.Len(t, checks, 2)
+	assert.Equal(t, SignatureBlock, checks[0].Type)
+	assert.Equal(t, SignatureCreator, checks[1].Type)
+	for _, check := range checks {
+		assert.True(t, check.Valid, check.Error)
+		assert.Equal(t, "OrdererMSP", check.MSPID)
+	}
+
+	// sampleblock is from another network, its signers are not issued by MSPs of this config
+	block, err = getBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	checks, err = validator.ValidateBlock(block)
+	assert.NoError(t, err)
+	assert.Len(t, checks, 4)
+	for _, check := range checks {
+		assert.False(t, check.Valid)
+		assert.False(t, check.Revoked)
+		assert.NotEmpty(t, check.Error)
+	}
+	assert.Equal(t, SignatureEndorsement, checks[3].Type)
+	assert.Equal(t, "Org2MSP", checks[3].MSPID)
+	assert.NotEmpty(t, checks[3].TxId)
+
+	_, err = ConfigFromBlock(block)
+	assert.Error(t, err)
+}
+
+func TestMSPValidatorRevocation(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	notBefore := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca.org1.example.com"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.AddDate(10, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	assert.NoError(t, err)
+
+	issue := func(serial int64) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "user", OrganizationalUnit: []string{"client"}},
+			NotBefore:    notBefore,
+			NotAfter:     notBefore.AddDate(1, 0, 0),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		assert.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	revoked, valid := issue(2), issue(3)
+
+	crl, err := ca.CreateCRL(rand.Reader, caKey, []pkix.RevokedCertificate{{SerialNumber: big.NewInt(2), RevocationTime: notBefore}}, notBefore, notBefore.AddDate(10, 0, 0))
+	assert.NoError(t, err)
+
+	validator, err := NewMSPValidator(newMSPConfig(t, &msp.FabricMSPConfig{
+		Name:           "Org1MSP",
+		RootCerts:      [][]byte{pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})},
+		RevocationList: [][]byte{pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl})},
+	}))
+	assert.NoError(t, err)
+
+	moment := notBefore.AddDate(0, 6, 0)
+	check := validator.ValidateCertificate("Org1MSP", valid, moment)
+	assert.True(t, check.Valid, check.Error)
+
+	check = validator.ValidateCertificate("Org1MSP", revoked, moment)
+	assert.False(t, check.Valid)
+	assert.True(t, check.Revoked)
+	assert.Equal(t, "certificate with serial number 2 is revoked", check.Error)
+
+	// expired at the moment
+	check = validator.ValidateCertificate("Org1MSP", valid, notBefore.AddDate(2, 0, 0))
+	assert.False(t, check.Valid)
+	assert.False(t, check.Revoked)
+
+	check = validator.ValidateCertificate("Org2MSP", valid, moment)
+	assert.False(t, check.Valid)
+	assert.Equal(t, "MSP Org2MSP is not defined in channel config", check.Error)
+}
+
+// newMSPConfig creates channel config with a single application organization.
+func newMSPConfig(t *testing.T, fabricConfig *msp.FabricMSPConfig) *common.Config {
+	fabricConfigBytes, err := proto.Marshal(fabricConfig)
+	assert.NoError(t, err)
+	mspConfigBytes, err := proto.Marshal(&msp.MSPConfig{Config: fabricConfigBytes})
+	assert.NoError(t, err)
+	return &common.Config{
+		ChannelGroup: &common.ConfigGroup{
+			Groups: map[string]*common.ConfigGroup{
+				"Application": {
+					Groups: map[string]*common.ConfigGroup{
+						fabricConfig.Name: {Values: map[string]*common.ConfigValue{"MSP": {Value: mspConfigBytes}}},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const channelGroupKey = "Channel"
+
+// MSPConfigs extracts Fabric MSP configurations of all organizations (application and orderer ones) defined in channel config.
+// The result is a map of MSP ID => *msp.FabricMSPConfig.
+func MSPConfigs(config *common.Config) (map[string]*msp.FabricMSPConfig, error) {
+	if config == nil || config.ChannelGroup == nil {
+		return nil, errors.New("empty channel config")
+	}
+	msps := make(map[string]*msp.FabricMSPConfig)
+	for _, section := range config.ChannelGroup.Groups {
+		for _, org := range section.Groups {
+			value, ok := org.Values["MSP"]
+			if !ok {
+				continue
+			}
+			mspConfig := &msp.MSPConfig{}
+			if err := proto.Unmarshal(value.Value, mspConfig); err != nil {
+				return nil, errors.Wrap(err, "error unmarshaling MSPConfig")
+			}
+			fabricConfig := &msp.FabricMSPConfig{}
+			if err := proto.Unmarshal(mspConfig.Config, fabricConfig); err != nil {
+				return nil, errors.Wrap(err, "error unmarshaling FabricMSPConfig")
+			}
+			msps[fabricConfig.Name] = fabricConfig
+		}
+	}
+	return msps, nil
+}
+
+// ConfigGroupByPath returns config group found by fully-qualified path, e.g. /Channel/Application/Org1MSP.
+func ConfigGroupByPath(config *common.Config, path string) (*common.ConfigGroup, bool) {
+	if config == nil || config.ChannelGroup == nil {
+		return nil, false
+	}
+	elements := splitPath(path)
+	if len(elements) == 0 || elements[0] != channelGroupKey {
+		return nil, false
+	}
+	group := config.ChannelGroup
+	for _, key := range elements[1:] {
+		next, ok := group.Groups[key]
+		if !ok {
+			return nil, false
+		}
+		group = next
+	}
+	return group, true
+}
+
+// EvaluatePolicy checks whether the identities satisfy the policy found by fully-qualified path (e.g. /Channel/Application/Admins) in channel config.
+// Only principals are matched here, signatures are not verified.
+func EvaluatePolicy(config *common.Config, path string, identities []*msp.SerializedIdentity) (bool, error) {
+	elements := splitPath(path)
+	if len(elements) < 2 {
+		return false, fmt.Errorf("invalid policy path %s", path)
+	}
+	groupPath := "/" + strings.Join(elements[:len(elements)-1], "/")
+	group, ok := ConfigGroupByPath(config, groupPath)
+	if !ok {
+		return false, fmt.Errorf("no config group %s", groupPath)
+	}
+	msps, err := MSPConfigs(config)
+	if err != nil {
+		return false, err
+	}
+	return evaluateGroupPolicy(group, elements[len(elements)-1], identities, msps)
+}
+
+func evaluateGroupPolicy(group *common.ConfigGroup, name string, identities []*msp.SerializedIdentity, msps map[string]*msp.FabricMSPConfig) (bool, error) {
+	configPolicy, ok := group.Policies[name]
+	if !ok || configPolicy.Policy == nil {
+		return false, fmt.Errorf("no policy %s", name)
+	}
+
+	switch common.Policy_PolicyType(configPolicy.Policy.Type) {
+	case common.Policy_SIGNATURE:
+		envelope := &common.SignaturePolicyEnvelope{}
+		if err := proto.Unmarshal(configPolicy.Policy.Value, envelope); err != nil {
+			return false, errors.Wrap(err, "error unmarshaling SignaturePolicyEnvelope")
+		}
+		return EvaluateSignaturePolicy(envelope, identities, msps), nil
+	case common.Policy_IMPLICIT_META:
+		implicitMeta := &common.ImplicitMetaPolicy{}
+		if err := proto.Unmarshal(configPolicy.Policy.Value, implicitMeta); err != nil {
+			return false, errors.Wrap(err, "error unmarshaling ImplicitMetaPolicy")
+		}
+		return EvaluateImplicitMetaPolicy(implicitMeta, group, identities, msps)
+	default:
+		return false, fmt.Errorf("unsupported policy type %d", configPolicy.Policy.Type)
+	}
+}
+
+// EvaluateImplicitMetaPolicy checks whether the identities satisfy ImplicitMeta policy of the config group,
+// i.e. whether enough (ANY, ALL or MAJORITY) of the subgroups' sub-policies are satisfied.
+// As in Fabric, the subgroup without the sub-policy counts as not satisfied.
+func EvaluateImplicitMetaPolicy(policy *common.ImplicitMetaPolicy, group *common.ConfigGroup, identities []*msp.SerializedIdentity, msps map[string]*msp.FabricMSPConfig) (bool, error) {
+	var satisfied int
+	for _, subgroup := range group.Groups {
+		if _, ok := subgroup.Policies[policy.SubPolicy]; !ok {
+			continue
+		}
+		ok, err := evaluateGroupPolicy(subgroup, policy.SubPolicy, identities, msps)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			satisfied++
+		}
+	}
+	return satisfied >= implicitMetaThreshold(policy.Rule, len(group.Groups)), nil
+}
+
+// implicitMetaThreshold returns the number of sub-policies that must be satisfied (the same way Fabric counts it).
+// As in Fabric, no sub-policies satisfy any rule.
+func implicitMetaThreshold(rule common.ImplicitMetaPolicy_Rule, subpolicies int) int {
+	if subpolicies == 0 {
+		return 0
+	}
+	switch rule {
+	case common.ImplicitMetaPolicy_ANY:
+		return 1
+	case common.ImplicitMetaPolicy_ALL:
+		return subpolicies
+	default:
+		return subpolicies/2 + 1
+	}
+}
+
+// EvaluateSignaturePolicy checks whether the identities satisfy signature policy.
+// As in Fabric, each identity can be used to satisfy only one principal of the policy.
+// 'msps' is used to determine roles (admin, client, peer, orderer) of the identities.
+func EvaluateSignaturePolicy(envelope *common.SignaturePolicyEnvelope, identities []*msp.SerializedIdentity, msps map[string]*msp.FabricMSPConfig) bool {
+	if envelope == nil || envelope.Rule == nil {
+		return false
+	}
+	identities = deduplicateIdentities(identities)
+	used := make([]bool, len(identities))
+	return evaluateSignaturePolicy(envelope.Rule, envelope.Identities, identities, used, msps)
+}
+
+func evaluateSignaturePolicy(rule *common.SignaturePolicy, principals []*msp.MSPPrincipal, identities []*msp.SerializedIdentity, used []bool, msps map[string]*msp.FabricMSPConfig) bool {
+	switch t := rule.Type.(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(principals) {
+			return false
+		}
+		for i, identity := range identities {
+			if used[i] {
+				continue
+			}
+			if SatisfiesPrincipal(identity, principals[t.SignedBy], msps) {
+				used[i] = true
+				return true
+			}
+		}
+		return false
+	case *common.SignaturePolicy_NOutOf_:
+		var verified int32
+		tmpUsed := make([]bool, len(used))
+		for _, subrule := range t.NOutOf.Rules {
+			copy(tmpUsed, used)
+			if evaluateSignaturePolicy(subrule, principals, identities, tmpUsed, msps) {
+				verified++
+				copy(used, tmpUsed)
+			}
+		}
+		return verified >= t.NOutOf.N
+	default:
+		return false
+	}
+}
+
+// SatisfiesPrincipal checks whether the identity satisfies MSP principal.
+// Roles are determined using admin certificates and NodeOUs from the MSP configuration.
+func SatisfiesPrincipal(identity *msp.SerializedIdentity, principal *msp.MSPPrincipal, msps map[string]*msp.FabricMSPConfig) bool {
+	switch principal.PrincipalClassification {
+	case msp.MSPPrincipal_ROLE:
+		role := &msp.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return false
+		}
+		if role.MspIdentifier != identity.Mspid {
+			return false
+		}
+		return hasRole(identity, role.Role, msps[identity.Mspid])
+	case msp.MSPPrincipal_ORGANIZATION_UNIT:
+		ou := &msp.OrganizationUnit{}
+		if err := proto.Unmarshal(principal.Principal, ou); err != nil {
+			return false
+		}
+		if ou.MspIdentifier != identity.Mspid {
+			return false
+		}
+		return hasOU(identity.IdBytes, ou.OrganizationalUnitIdentifier)
+	case msp.MSPPrincipal_IDENTITY:
+		principalIdentity := &msp.SerializedIdentity{}
+		if err := proto.Unmarshal(principal.Principal, principalIdentity); err != nil {
+			return false
+		}
+		return principalIdentity.Mspid == identity.Mspid && bytes.Equal(principalIdentity.IdBytes, identity.IdBytes)
+	default:
+		return false
+	}
+}
+
+func hasRole(identity *msp.SerializedIdentity, role msp.MSPRole_MSPRoleType, mspConfig *msp.FabricMSPConfig) bool {
+	if role == msp.MSPRole_MEMBER {
+		return true
+	}
+	if mspConfig == nil {
+		return false
+	}
+
+	if role == msp.MSPRole_ADMIN {
+		for _, admin := range mspConfig.Admins {
+			if sameCertificate(admin, identity.IdBytes) {
+				return true
+			}
+		}
+	}
+
+	nodeOUs := mspConfig.FabricNodeOus
+	if nodeOUs == nil || !nodeOUs.Enable {
+		return false
+	}
+	var ouIdentifier *msp.FabricOUIdentifier
+	switch role {
+	case msp.MSPRole_ADMIN:
+		ouIdentifier = nodeOUs.AdminOuIdentifier
+	case msp.MSPRole_CLIENT:
+		ouIdentifier = nodeOUs.ClientOuIdentifier
+	case msp.MSPRole_PEER:
+		ouIdentifier = nodeOUs.PeerOuIdentifier
+	case msp.MSPRole_ORDERER:
+		ouIdentifier = nodeOUs.OrdererOuIdentifier
+	}
+	if ouIdentifier == nil {
+		return false
+	}
+	return hasOU(identity.IdBytes, ouIdentifier.OrganizationalUnitIdentifier)
+}
+
+func hasOU(certPEM []byte, ou string) bool {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return false
+	}
+	for _, certOU := range cert.Subject.OrganizationalUnit {
+		if certOU == ou {
+			return true
+		}
+	}
+	return false
+}
+
+func sameCertificate(a, b []byte) bool {
+	certA, err := parseCertificate(a)
+	if err != nil {
+		return false
+	}
+	certB, err := parseCertificate(b)
+	if err != nil {
+		return false
+	}
+	return certA.Equal(certB)
+}
+
+// parseCertificate parses pem-encoded (or DER-encoded) x509 certificate.
+func parseCertificate(raw []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	return x509.ParseCertificate(raw)
+}
+
+func deduplicateIdentities(identities []*msp.SerializedIdentity) []*msp.SerializedIdentity {
+	var result []*msp.SerializedIdentity
+	for _, identity := range identities {
+		duplicate := false
+		for _, seen := range result {
+			if seen.Mspid == identity.Mspid && bytes.Equal(seen.IdBytes, identity.IdBytes) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			result = append(result, identity)
+		}
+	}
+	return result
+}
+
+func splitPath(path string) []string {
+	var elements []string
+	for _, element := range strings.Split(path, "/") {
+		if element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
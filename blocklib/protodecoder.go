@@ -0,0 +1,253 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io/ioutil"
+	"regexp"
+	"sync"
+)
+
+// ProtoDecoder decodes protobuf-encoded state values and chaincode event payloads into JSON-friendly structures
+// (map[string]interface{}, []interface{}, string, float64, bool) using message descriptors loaded at runtime.
+// Messages are looked up in the loaded descriptor sets first and then among the messages compiled into the binary.
+type ProtoDecoder struct {
+	mu     sync.RWMutex
+	files  *protoregistry.Files
+	keys   map[string][]keyMapping      // chaincode => key patterns
+	events map[string]map[string]string // chaincode => event name => message
+}
+
+type keyMapping struct {
+	pattern *regexp.Regexp
+	message string
+}
+
+func NewProtoDecoder() *ProtoDecoder {
+	return &ProtoDecoder{
+		files:  new(protoregistry.Files),
+		keys:   make(map[string][]keyMapping),
+		events: make(map[string]map[string]string),
+	}
+}
+
+// LoadDescriptorSetFile loads compiled FileDescriptorSet (e.g. produced by 'protoc --include_imports --descriptor_set_out').
+func (d *ProtoDecoder) LoadDescriptorSetFile(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return d.LoadDescriptorSet(raw)
+}
+
+// LoadDescriptorSet loads marshaled FileDescriptorSet.
+// Imports which are not included into the set are resolved among the files compiled into the binary.
+func (d *ProtoDecoder) LoadDescriptorSet(raw []byte) error {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := protov2.Unmarshal(raw, set); err != nil {
+		return errors.Wrap(err, "error unmarshaling FileDescriptorSet")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// files in the set are not necessarily ordered by dependencies, so register them until no progress is made
+	pending := set.File
+	for len(pending) > 0 {
+		var (
+			unresolved []*descriptorpb.FileDescriptorProto
+			lastErr    error
+		)
+		for _, fileProto := range pending {
+			if _, err := d.files.FindFileByPath(fileProto.GetName()); err == nil {
+				continue
+			}
+			file, err := protodesc.NewFile(fileProto, d)
+			if err != nil {
+				unresolved, lastErr = append(unresolved, fileProto), err
+				continue
+			}
+			if err := d.files.RegisterFile(file); err != nil {
+				return err
+			}
+		}
+		if len(unresolved) == len(pending) {
+			return errors.Wrapf(lastErr, "failed to load %s", unresolved[0].GetName())
+		}
+		pending = unresolved
+	}
+	return nil
+}
+
+// FindFileByPath looks up file among the loaded and compiled-in files (implements protodesc.Resolver).
+func (d *ProtoDecoder) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if file, err := d.files.FindFileByPath(path); err == nil {
+		return file, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+// FindDescriptorByName looks up descriptor among the loaded and compiled-in files (implements protodesc.Resolver).
+func (d *ProtoDecoder) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if descriptor, err := d.files.FindDescriptorByName(name); err == nil {
+		return descriptor, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// MapKey sets message type of the values stored by chaincode under keys matching the pattern (regular expression).
+// Patterns are checked in the order they were mapped.
+func (d *ProtoDecoder) MapKey(chaincode, keyPattern, message string) error {
+	pattern, err := regexp.Compile(keyPattern)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.keys[chaincode] = append(d.keys[chaincode], keyMapping{pattern: pattern, message: message})
+	return nil
+}
+
+// MapEvent sets message type of the payload of chaincode events with the name.
+func (d *ProtoDecoder) MapEvent(chaincode, eventName, message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.events[chaincode] == nil {
+		d.events[chaincode] = make(map[string]string)
+	}
+	d.events[chaincode][eventName] = message
+}
+
+// KeyMessage returns message type mapped to the key of chaincode.
+func (d *ProtoDecoder) KeyMessage(chaincode, key string) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, mapping := range d.keys[chaincode] {
+		if mapping.pattern.MatchString(key) {
+			return mapping.message, true
+		}
+	}
+	return "", false
+}
+
+// EventMessage returns message type mapped to the event of chaincode.
+func (d *ProtoDecoder) EventMessage(chaincode, eventName string) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	message, ok := d.events[chaincode][eventName]
+	return message, ok
+}
+
+// Decode unmarshals raw bytes as the message with fully-qualified name and converts it into JSON-friendly structure.
+func (d *ProtoDecoder) Decode(message string, raw []byte) (interface{}, error) {
+	d.mu.RLock()
+	descriptor, err := d.FindDescriptorByName(protoreflect.FullName(message))
+	d.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("unknown protobuf message %s: %w", message, err)
+	}
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a protobuf message", message)
+	}
+
+	dynamicMessage := dynamicpb.NewMessage(messageDescriptor)
+	if err := protov2.Unmarshal(raw, dynamicMessage); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshaling %s", message)
+	}
+	marshaled, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(dynamicMessage)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(marshaled, &value)
+	return value, err
+}
+
+// DecodeValue decodes state value written by chaincode under the key.
+// It returns false if no message type is mapped to the key.
+func (d *ProtoDecoder) DecodeValue(chaincode, key string, value []byte) (interface{}, bool, error) {
+	message, ok := d.KeyMessage(chaincode, key)
+	if !ok {
+		return nil, false, nil
+	}
+	decoded, err := d.Decode(message, value)
+	return decoded, true, err
+}
+
+// DecodeEvent decodes payload of the chaincode event.
+// It returns false if no message type is mapped to the event.
+func (d *ProtoDecoder) DecodeEvent(event *peer.ChaincodeEvent) (interface{}, bool, error) {
+	if event == nil {
+		return nil, false, nil
+	}
+	message, ok := d.EventMessage(event.ChaincodeId, event.EventName)
+	if !ok {
+		return nil, false, nil
+	}
+	decoded, err := d.Decode(message, event.Payload)
+	return decoded, true, err
+}
+
+// DecodedValue is a state value or chaincode event payload decoded with the mapped message type.
+type DecodedValue struct {
+	Chaincode string
+	Key       string // state key (empty for events)
+	EventName string // event name (empty for state values)
+	Message   string
+	Value     interface{}
+}
+
+// DecodeAction decodes state values written by the action and payload of its chaincode event.
+// Deleted keys and values without mapped message type are skipped.
+func (d *ProtoDecoder) DecodeAction(action *Action) ([]DecodedValue, error) {
+	rwsets, err := action.RWSets()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DecodedValue
+	for _, rwset := range rwsets {
+		for _, write := range rwset.KVRWSet.Writes {
+			if write.IsDelete {
+				continue
+			}
+			message, ok := d.KeyMessage(rwset.NameSpace, write.Key)
+			if !ok {
+				continue
+			}
+			value, err := d.Decode(message, write.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode value of key %s", write.Key)
+			}
+			result = append(result, DecodedValue{Chaincode: rwset.NameSpace, Key: write.Key, Message: message, Value: value})
+		}
+	}
+
+	event, err := action.ChaincodeEvent()
+	if err != nil {
+		return nil, err
+	}
+	if message, ok := d.EventMessage(event.ChaincodeId, event.EventName); ok {
+		value, err := d.Decode(message, event.Payload)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode payload of event %s", event.EventName)
+		}
+		result = append(result, DecodedValue{Chaincode: event.ChaincodeId, EventName: event.EventName, Message: message, Value: value})
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"testing"
+)
+
+// carDescriptorSet describes message test.Car which imports google.protobuf.Timestamp (not included into the set).
+var carDescriptorSet = &descriptorpb.FileDescriptorSet{
+	File: []*descriptorpb.FileDescriptorProto{{
+		Name:       protov2.String("car.proto"),
+		Package:    protov2.String("test"),
+		Syntax:     protov2.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: protov2.String("Car"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				newField("make", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				newField("owner_name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				newField("registered", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+			},
+		}},
+	}},
+}
+
+func newField(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:   protov2.String(name),
+		Number: protov2.Int32(number),
+		Type:   fieldType.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if typeName != "" {
+		field.TypeName = protov2.String(typeName)
+	}
+	return field
+}
+
+func newProtoDecoder(t *testing.T) *ProtoDecoder {
+	raw, err := protov2.Marshal(carDescriptorSet)
+	assert.NoError(t, err)
+	decoder := NewProtoDecoder()
+	assert.NoError(t, decoder.LoadDescriptorSet(raw))
+	return decoder
+}
+
+func marshalCar(t *testing.T, decoder *ProtoDecoder, make, owner string) []byte {
+	descriptor, err := decoder.FindDescriptorByName("test.Car")
+	assert.NoError(t, err)
+	car := dynamicpb.NewMessage(descriptor.(protoreflect.MessageDescriptor))
+	car.Set(car.Descriptor().Fields().ByName("make"), protoreflect.ValueOfString(make))
+	car.Set(car.Descriptor().Fields().ByName("owner_name"), protoreflect.ValueOfString(owner))
+	raw, err := protov2.Marshal(car)
+	assert.NoError(t, err)
+	return raw
+}
+
+func TestProtoDecoderDecode(t *testing.T) {
+	decoder := newProtoDecoder(t)
+
+	t.Run("loaded message", func(t *testing.T) {
+		value, err := decoder.Decode("test.Car", marshalCar(t, decoder, "VW", "Mary"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"make": "VW", "owner_name": "Mary"}, value)
+	})
+
+	t.Run("compiled message", func(t *testing.T) {
+		raw, err := proto.Marshal(&common.BlockHeader{Number: 7})
+		assert.NoError(t, err)
+		value, err := decoder.Decode("common.BlockHeader", raw)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"number": "7"}, value)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		_, err := decoder.Decode("test.Boat", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestProtoDecoderLoadUnresolved(t *testing.T) {
+	set := protov2.Clone(carDescriptorSet).(*descriptorpb.FileDescriptorSet)
+	set.File[0].Dependency = []string{"unknown.proto"}
+	raw, err := protov2.Marshal(set)
+	assert.NoError(t, err)
+	assert.Error(t, NewProtoDecoder().LoadDescriptorSet(raw))
+}
+
+func TestProtoDecoderDecodeAction(t *testing.T) {
+	decoder := newProtoDecoder(t)
+	assert.NoError(t, decoder.MapKey("fabcar", "^CAR[0-9]+$", "test.Car"))
+	decoder.MapEvent("fabcar", "CarCreated", "test.Car")
+
+	car := marshalCar(t, decoder, "VW", "Mary")
+	action := newAction(t, "fabcar", [][]byte{[]byte("createCar")}, &rwset.TxReadWriteSet{
+		NsRwset: []*rwset.NsReadWriteSet{
+			newNsRWSet(t, "fabcar", &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{
+				{Key: "CAR11", Value: car},
+				{Key: "CAR12", IsDelete: true},
+				{Key: "owners", Value: []byte("not a car")},
+			}}),
+		},
+	})
+	// add event to the chaincode action
+	ccAction, err := action.ChaincodeAction()
+	assert.NoError(t, err)
+	ccAction.Events, err = proto.Marshal(&peer.ChaincodeEvent{ChaincodeId: "fabcar", EventName: "CarCreated", Payload: car})
+	assert.NoError(t, err)
+	extension, err := proto.Marshal(ccAction)
+	assert.NoError(t, err)
+	action.Payload.Action.ProposalResponsePayload, err = proto.Marshal(&peer.ProposalResponsePayload{Extension: extension})
+	assert.NoError(t, err)
+
+	values, err := decoder.DecodeAction(action)
+	assert.NoError(t, err)
+	expected := map[string]interface{}{"make": "VW", "owner_name": "Mary"}
+	assert.Equal(t, []DecodedValue{
+		{Chaincode: "fabcar", Key: "CAR11", Message: "test.Car", Value: expected},
+		{Chaincode: "fabcar", EventName: "CarCreated", Message: "test.Car", Value: expected},
+	}, values)
+}
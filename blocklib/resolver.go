@@ -0,0 +1,81 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"sync"
+)
+
+// ConfigBlockResolver is used to get config blocks referred by other blocks (see Block.LastConfig),
+// e.g. to find identities of the BFT consenters which signed the block.
+type ConfigBlockResolver interface {
+	// ConfigBlock returns config block with number 'number' of the channel.
+	ConfigBlock(channel string, number uint64) (*common.Block, error)
+}
+
+// ConfigBlockResolverFunc is an adapter to use ordinary functions as ConfigBlockResolver.
+type ConfigBlockResolverFunc func(channel string, number uint64) (*common.Block, error)
+
+func (f ConfigBlockResolverFunc) ConfigBlock(channel string, number uint64) (*common.Block, error) {
+	return f(channel, number)
+}
+
+// LedgerConfigResolver queries config blocks from the peer. The ledger client is bound to a single channel.
+type LedgerConfigResolver struct {
+	cli *ledger.Client
+}
+
+func NewLedgerConfigResolver(cli *ledger.Client) *LedgerConfigResolver {
+	return &LedgerConfigResolver{cli: cli}
+}
+
+func (r *LedgerConfigResolver) ConfigBlock(_ string, number uint64) (*common.Block, error) {
+	return r.cli.QueryBlock(number)
+}
+
+// CachingConfigResolver keeps config blocks in memory. Blocks missing in the cache are requested from the next resolver (if any) and cached.
+// Without the next resolver it works offline: config blocks must be added with Put (e.g. as they are met while crawling).
+type CachingConfigResolver struct {
+	mu     sync.RWMutex
+	next   ConfigBlockResolver
+	blocks map[string]map[uint64]*common.Block // channel => number => block
+}
+
+// NewCachingConfigResolver creates CachingConfigResolver, 'next' may be nil.
+func NewCachingConfigResolver(next ConfigBlockResolver) *CachingConfigResolver {
+	return &CachingConfigResolver{next: next, blocks: make(map[string]map[uint64]*common.Block)}
+}
+
+// Put adds config block of the channel to the cache.
+func (r *CachingConfigResolver) Put(channel string, block *common.Block) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.blocks[channel] == nil {
+		r.blocks[channel] = make(map[uint64]*common.Block)
+	}
+	r.blocks[channel][block.Header.Number] = block
+}
+
+func (r *CachingConfigResolver) ConfigBlock(channel string, number uint64) (*common.Block, error) {
+	r.mu.RLock()
+	block, ok := r.blocks[channel][number]
+	r.mu.RUnlock()
+	if ok {
+		return block, nil
+	}
+	if r.next == nil {
+		return nil, fmt.Errorf("config block %d of channel %s is not cached", number, channel)
+	}
+	block, err := r.next.ConfigBlock(channel, number)
+	if err != nil {
+		return nil, err
+	}
+	r.Put(channel, block)
+	return block, nil
+}
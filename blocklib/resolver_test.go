@@ -0,0 +1,127 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/newity/crawler/blocklib/smartbft"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newSmartBFTConfigBlock makes config block of the channel with the given number from config.pb
+// replacing its consensus type with smartbft with consenters with the given IDs (and fake identities).
+func newSmartBFTConfigBlock(t *testing.T, channel string, number uint64, consenterIds ...uint64) *common.Block {
+	var consenters []*smartbft.Consenter
+	for _, id := range consenterIds {
+		identity, err := proto.Marshal(&msp.SerializedIdentity{Mspid: fmt.Sprintf("Orderer%dMSP", id), IdBytes: []byte(fmt.Sprintf("cert%d", id))})
+		assert.NoError(t, err)
+		consenters = append(consenters, &smartbft.Consenter{ConsenterId: id, Identity: identity})
+	}
+	return newSmartBFTConfigBlockWithConsenters(t, channel, number, consenters)
+}
+
+// newSmartBFTConfigBlockWithConsenters makes config block of the channel with the given number from config.pb
+// replacing its consensus type with smartbft with the given consenters.
+func newSmartBFTConfigBlockWithConsenters(t *testing.T, channel string, number uint64, consenters []*smartbft.Consenter) *common.Block {
+	block, err := getBlock("./mock/config.pb")
+	assert.NoError(t, err)
+
+	envelope := &common.Envelope{}
+	assert.NoError(t, proto.Unmarshal(block.Data.Data[0], envelope))
+	payload := &common.Payload{}
+	assert.NoError(t, proto.Unmarshal(envelope.Payload, payload))
+	configEnvelope := &common.ConfigEnvelope{}
+	assert.NoError(t, proto.Unmarshal(payload.Data, configEnvelope))
+
+	metadata := &smartbft.ConfigMetadata{Consenters: consenters}
+	metadataBytes, err := proto.Marshal(metadata)
+	assert.NoError(t, err)
+	consensusType, err := proto.Marshal(&orderer.ConsensusType{Type: ConsensusBFT, Metadata: metadataBytes})
+	assert.NoError(t, err)
+	configEnvelope.Config.ChannelGroup.Groups["Orderer"].Values["ConsensusType"].Value = consensusType
+
+	payload.Data, err = proto.Marshal(configEnvelope)
+	assert.NoError(t, err)
+	channelHeader := &common.ChannelHeader{}
+	assert.NoError(t, proto.Unmarshal(payload.Header.ChannelHeader, channelHeader))
+	channelHeader.ChannelId = channel
+	payload.Header.ChannelHeader, err = proto.Marshal(channelHeader)
+	assert.NoError(t, err)
+	envelope.Payload, err = proto.Marshal(payload)
+	assert.NoError(t, err)
+	block.Data.Data[0], err = proto.Marshal(envelope)
+	assert.NoError(t, err)
+	block.Header.Number = number
+	return block
+}
+
+func TestFromBFTFabricBlockWithResolver(t *testing.T) {
+	block, err := getBlock("./mock/withevents.pb")
+	assert.NoError(t, err)
+
+	resolver := NewCachingConfigResolver(nil)
+	resolver.Put("cc", newSmartBFTConfigBlock(t, "cc", 4, 1, 3, 4, 5, 6, 7))
+
+	b, err := FromBFTFabricBlockWithResolver(resolver, block)
+	assert.NoError(t, err)
+	signatures := b.OrderersSignatures()
+	assert.Len(t, signatures, 5)
+	for i, id := range []uint64{4, 6, 3, 5, 7} {
+		assert.Equal(t, id, signatures[i].ConsenterId)
+		assert.Equal(t, fmt.Sprintf("Orderer%dMSP", id), signatures[i].MSPID)
+		assert.Equal(t, []byte(fmt.Sprintf("cert%d", id)), signatures[i].Cert)
+		assert.Len(t, signatures[i].Nonce, 24)
+		assert.NotEmpty(t, signatures[i].Signature)
+	}
+
+	identities, err := GetBFTOrderersIdentitiesWithResolver(resolver, block)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 5)
+	assert.Equal(t, uint64(4), identities[0].ConsenterId)
+	assert.Equal(t, "Orderer4MSP", identities[0].Identity.Mspid)
+}
+
+func TestFromBFTFabricBlockWithResolverUnknownSigner(t *testing.T) {
+	block, err := getBlock("./mock/withevents.pb")
+	assert.NoError(t, err)
+
+	resolver := NewCachingConfigResolver(nil)
+	resolver.Put("cc", newSmartBFTConfigBlock(t, "cc", 4, 3, 4, 5, 6))
+
+	b, err := FromBFTFabricBlockWithResolver(resolver, block)
+	assert.NoError(t, err)
+	signatures := b.OrderersSignatures()
+	assert.Len(t, signatures, 5)
+	assert.Equal(t, uint64(7), signatures[4].ConsenterId)
+	assert.Empty(t, signatures[4].Cert)
+	assert.Empty(t, signatures[4].MSPID)
+
+	identities, err := GetBFTOrderersIdentitiesWithResolver(resolver, block)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 4)
+}
+
+func TestFromBFTFabricBlockWithResolverNoConfig(t *testing.T) {
+	block, err := getBlock("./mock/withevents.pb")
+	assert.NoError(t, err)
+
+	_, err = FromBFTFabricBlockWithResolver(NewCachingConfigResolver(nil), block)
+	assert.EqualError(t, err, "couldn't get config block 4: config block 4 of channel cc is not cached")
+
+	next := ConfigBlockResolverFunc(func(channel string, number uint64) (*common.Block, error) {
+		return newSmartBFTConfigBlock(t, channel, number, 3, 4, 5, 6, 7), nil
+	})
+	resolver := NewCachingConfigResolver(next)
+	_, err = FromBFTFabricBlockWithResolver(resolver, block)
+	assert.NoError(t, err)
+	_, err = resolver.ConfigBlock("cc", 4)
+	assert.NoError(t, err)
+}
@@ -6,12 +6,15 @@ SPDX-License-Identifier: Apache-2.0
 package blocklib
 
 import (
+	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/msp"
 	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
 	"time"
 )
 
@@ -23,6 +26,60 @@ type Tx struct {
 	validationStatus string
 }
 
+// NewTx creates transaction from the envelope bytes and its validation code and status (e.g. to restore it from storage).
+func NewTx(data []byte, validationCode int32, validationStatus string) Tx {
+	return Tx{Data: data, validationCode: validationCode, validationStatus: validationStatus}
+}
+
+type txJSON struct {
+	Data             []byte `json:"data"`
+	ValidationCode   int32  `json:"validation_code"`
+	ValidationStatus string `json:"validation_status,omitempty"`
+}
+
+// MarshalJSON encodes the transaction with its validation code and status.
+func (tx Tx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(txJSON{Data: tx.Data, ValidationCode: tx.validationCode, ValidationStatus: tx.validationStatus})
+}
+
+// UnmarshalJSON decodes the transaction encoded by MarshalJSON.
+func (tx *Tx) UnmarshalJSON(data []byte) error {
+	decoded := txJSON{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*tx = NewTx(decoded.Data, decoded.ValidationCode, decoded.ValidationStatus)
+	return nil
+}
+
+// GobEncode encodes the transaction with its validation code and status (gob keeps exported fields only):
+// varint validation code, uvarint length of the status, the status and the envelope bytes.
+func (tx Tx) GobEncode() ([]byte, error) {
+	header := make([]byte, 2*binary.MaxVarintLen64)
+	n := binary.PutVarint(header, int64(tx.validationCode))
+	n += binary.PutUvarint(header[n:], uint64(len(tx.validationStatus)))
+	encoded := make([]byte, 0, n+len(tx.validationStatus)+len(tx.Data))
+	encoded = append(encoded, header[:n]...)
+	encoded = append(encoded, tx.validationStatus...)
+	return append(encoded, tx.Data...), nil
+}
+
+// GobDecode decodes the transaction encoded by GobEncode.
+func (tx *Tx) GobDecode(data []byte) error {
+	code, n := binary.Varint(data)
+	if n <= 0 {
+		return errors.New("failed to decode transaction validation code")
+	}
+	data = data[n:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < length {
+		return errors.New("failed to decode transaction validation status")
+	}
+	data = data[n:]
+	*tx = NewTx(append([]byte(nil), data[length:]...), int32(code), string(data[:length]))
+	return nil
+}
+
 // IsValid checks if transaction with specified number (txNumber int) in block (block *common.Block) is valid or not and returns corresponding bool value.
 func (tx *Tx) IsValid() bool {
 	return tx.validationCode == 0
@@ -0,0 +1,275 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+// Types of verified signatures
+const (
+	SignatureBlock       = "block"       // orderer signature over block header and metadata
+	SignatureCreator     = "creator"     // transaction creator signature over envelope payload
+	SignatureEndorsement = "endorsement" // endorser signature over proposal response payload and endorser
+)
+
+// SignatureCheck is a result of the verification of a single signature.
+type SignatureCheck struct {
+	Type        string
+	TxIndex     int    // -1 for block signatures
+	ActionIndex int    // -1 for block and creator signatures
+	Index       int    // number of the signature in block metadata or among action endorsements
+	ConsenterId uint64 // ID of the BFT consenter (BFT block signatures only)
+	MSPID       string
+	Cert        []byte // pem-encoded
+	Valid       bool
+	// Unverifiable is set if the signer can't be resolved from the block itself (BFT block signatures, see VerifyBlockWithResolver).
+	// Such checks are neither passed nor failed.
+	Unverifiable bool
+	Error        string // reason of the failure
+}
+
+// VerificationReport contains results of the cryptographic verification of the block.
+type VerificationReport struct {
+	BlockNumber      uint64
+	Signatures       []SignatureCheck
+	TamperIndicators []TamperIndicator // mismatches of TxID and proposal hashes (see Tx.CheckIntegrity)
+	Quorum           *BFTQuorumReport  // quorum of BFT consenters (see VerifyBlockWithResolver), nil for other blocks
+}
+
+// Valid returns true if all the checks in the report passed and BFT block is signed by the quorum of consenters.
+func (r *VerificationReport) Valid() bool {
+	return len(r.Failures()) == 0 && len(r.TamperIndicators) == 0 && (r.Quorum == nil || r.Quorum.Satisfied())
+}
+
+// Failures returns all failed signature checks. Unverifiable checks are not failures.
+func (r *VerificationReport) Failures() []SignatureCheck {
+	var failures []SignatureCheck
+	for _, check := range r.Signatures {
+		if !check.Valid && !check.Unverifiable {
+			failures = append(failures, check)
+		}
+	}
+	return failures
+}
+
+// Unverifiable returns signature checks whose signers can't be resolved from the block.
+func (r *VerificationReport) Unverifiable() []SignatureCheck {
+	var unverifiable []SignatureCheck
+	for _, check := range r.Signatures {
+		if check.Unverifiable {
+			unverifiable = append(unverifiable, check)
+		}
+	}
+	return unverifiable
+}
+
+// VerifyBlock verifies orderer signatures of the block, creator signatures of all its transactions
+// and endorsement signatures of all actions of endorser transactions. It also checks integrity of transaction IDs and proposal hashes.
+// Error is returned only if the block can't be decoded, failed checks are reported in VerificationReport.
+// Orderer signatures of BFT blocks are reported as unverifiable, use VerifyBlockWithResolver to verify them.
+func VerifyBlock(block *common.Block) (*VerificationReport, error) {
+	b, err := FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	blockChecks, err := b.VerifySignatures()
+	if err != nil {
+		return nil, err
+	}
+	report := &VerificationReport{BlockNumber: b.Number(), Signatures: blockChecks}
+
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+	for txIndex := range txs {
+		tx := &txs[txIndex]
+		check := tx.VerifyCreatorSignature()
+		check.TxIndex = txIndex
+		report.Signatures = append(report.Signatures, check)
+		for _, indicator := range tx.CheckIntegrity() {
+			indicator.TxIndex = txIndex
+			report.TamperIndicators = append(report.TamperIndicators, indicator)
+		}
+
+		header, err := tx.ChannelHeader()
+		if err != nil || common.HeaderType(header.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+			continue
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			report.Signatures = append(report.Signatures, SignatureCheck{
+				Type:        SignatureEndorsement,
+				TxIndex:     txIndex,
+				ActionIndex: -1,
+				Error:       fmt.Sprintf("failed to get actions: %s", err),
+			})
+			continue
+		}
+		for actionIndex := range actions {
+			for _, check := range actions[actionIndex].VerifyEndorsements() {
+				check.TxIndex = txIndex
+				check.ActionIndex = actionIndex
+				report.Signatures = append(report.Signatures, check)
+			}
+		}
+	}
+	return report, nil
+}
+
+// VerifyBlockWithResolver verifies the block as VerifyBlock does. Orderer signatures of BFT blocks are verified
+// against consenters of the last config block got from resolver (see VerifyBFTQuorum), the block must be signed by the quorum of consenters.
+func VerifyBlockWithResolver(resolver ConfigBlockResolver, block *common.Block) (*VerificationReport, error) {
+	report, err := VerifyBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Unverifiable()) == 0 {
+		return report, nil
+	}
+
+	quorum, err := VerifyBFTQuorum(resolver, block)
+	if err != nil {
+		return nil, err
+	}
+	signatures := quorum.Signatures
+	for _, check := range report.Signatures {
+		if check.Type != SignatureBlock {
+			signatures = append(signatures, check)
+		}
+	}
+	report.Signatures = signatures
+	report.Quorum = quorum
+	return report, nil
+}
+
+// VerifySignatures verifies signatures of orderers over the block header and the signatures metadata.
+// Signatures of BFT orderers have no creator and are reported as unverifiable.
+func (b *Block) VerifySignatures() ([]SignatureCheck, error) {
+	metadata := &common.Metadata{}
+	if err := proto.Unmarshal(b.Metadata[common.BlockMetadataIndex_SIGNATURES], metadata); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshaling metadata from block at index [%s]", common.BlockMetadataIndex_SIGNATURES)
+	}
+	headerBytes := BlockHeaderBytes(&common.BlockHeader{
+		Number:       b.number,
+		PreviousHash: b.prevhash,
+		DataHash:     b.datahash,
+	})
+
+	var checks []SignatureCheck
+	for i, metadataSignature := range metadata.Signatures {
+		check := SignatureCheck{Type: SignatureBlock, TxIndex: -1, ActionIndex: -1, Index: i}
+		sigHdr := &common.SignatureHeader{}
+		if err := proto.Unmarshal(metadataSignature.SignatureHeader, sigHdr); err != nil {
+			check.Error = fmt.Sprintf("error unmarshaling SignatureHeader: %s", err)
+			checks = append(checks, check)
+			continue
+		}
+		if len(sigHdr.Creator) == 0 {
+			// BFT orderers identify the signer by consenter ID instead of serialized identity,
+			// the consenter set of the last config block is required to verify the signature
+			check.Unverifiable = true
+			check.Error = "no creator in SignatureHeader"
+			checks = append(checks, check)
+			continue
+		}
+		message := concat(metadata.Value, metadataSignature.SignatureHeader, headerBytes)
+		checks = append(checks, verifySerialized(check, sigHdr.Creator, metadataSignature.Signature, message))
+	}
+	return checks, nil
+}
+
+// VerifyCreatorSignature verifies signature of the transaction creator over the envelope payload.
+func (tx *Tx) VerifyCreatorSignature() SignatureCheck {
+	check := SignatureCheck{Type: SignatureCreator, ActionIndex: -1}
+	envelope, err := tx.Envelope()
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to get envelope: %s", err)
+		return check
+	}
+	sighdr, err := tx.SignatureHeader()
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to get signature header: %s", err)
+		return check
+	}
+	return verifySerialized(check, sighdr.Creator, envelope.Signature, envelope.Payload)
+}
+
+// VerifyEndorsements verifies signatures of endorsers over the proposal response payload concatenated with the endorser identity.
+func (a *Action) VerifyEndorsements() []SignatureCheck {
+	var checks []SignatureCheck
+	for i, endorsement := range a.Endorsements() {
+		check := SignatureCheck{Type: SignatureEndorsement, Index: i}
+		message := concat(a.Payload.Action.ProposalResponsePayload, endorsement.Endorser)
+		checks = append(checks, verifySerialized(check, endorsement.Endorser, endorsement.Signature, message))
+	}
+	return checks
+}
+
+// VerifySignature verifies signature made by the owner of pem-encoded x509 certificate over the message.
+// ECDSA and Ed25519 keys are supported. ECDSA signatures are verified over SHA256 digest whatever the curve
+// and must be low-S, as Fabric MSP identities sign and verify them.
+func VerifySignature(cert, signature, message []byte) error {
+	certificate, err := parseCertificate(cert)
+	if err != nil {
+		return err
+	}
+
+	switch key := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		ecdsaSignature := struct{ R, S *big.Int }{}
+		if _, err := asn1.Unmarshal(signature, &ecdsaSignature); err != nil {
+			return errors.Wrap(err, "failed to unmarshal ECDSA signature")
+		}
+		if ecdsaSignature.S.Cmp(new(big.Int).Rsh(key.Params().N, 1)) > 0 {
+			return errors.New("ECDSA signature is not low-S")
+		}
+		digest := sha256.Sum256(message)
+		if !ecdsa.Verify(key, digest[:], ecdsaSignature.R, ecdsaSignature.S) {
+			return errors.New("ECDSA signature is invalid")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return errors.New("Ed25519 signature is invalid")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+func verifySerialized(check SignatureCheck, serialized, signature, message []byte) SignatureCheck {
+	identity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serialized, identity); err != nil {
+		check.Error = fmt.Sprintf("error unmarshaling SerializedIdentity: %s", err)
+		return check
+	}
+	check.MSPID, check.Cert = identity.Mspid, identity.IdBytes
+	if err := VerifySignature(identity.IdBytes, signature, message); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.Valid = true
+	return check
+}
+
+func concat(parts ...[]byte) []byte {
+	var result []byte
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
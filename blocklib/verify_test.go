@@ -0,0 +1,119 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blocklib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/asn1"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyBlock(t *testing.T) {
+	for _, path := range []string{"./mock/sampleblock.pb", "./mock/mvcc_read_conflict.pb", "./mock/config.pb", "./mock/genesis.pb"} {
+		block, err := getBlock(path)
+		assert.NoError(t, err)
+		report, err := VerifyBlock(block)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid(), path)
+	}
+
+	block, err := getBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+	report, err := VerifyBlock(block)
+	assert.NoError(t, err)
+	// one orderer signature, one creator signature and two endorsements
+	assert.Len(t, report.Signatures, 4)
+	assert.Equal(t, SignatureBlock, report.Signatures[0].Type)
+	assert.Equal(t, "OrdererMSP", report.Signatures[0].MSPID)
+	assert.Equal(t, SignatureCreator, report.Signatures[1].Type)
+	assert.Equal(t, "Org1MSP", report.Signatures[1].MSPID)
+	assert.Equal(t, SignatureEndorsement, report.Signatures[3].Type)
+	assert.Equal(t, 1, report.Signatures[3].Index)
+}
+
+func TestVerifyBFTBlock(t *testing.T) {
+	block, err := getBlock("./mock/withevents.pb")
+	assert.NoError(t, err)
+	report, err := VerifyBlock(block)
+	assert.NoError(t, err)
+
+	// signers of BFT blocks can't be resolved from the block itself, tx signatures are still verified
+	for _, check := range report.Signatures {
+		if check.Type == SignatureBlock {
+			assert.False(t, check.Valid)
+			assert.True(t, check.Unverifiable)
+			assert.Equal(t, "no creator in SignatureHeader", check.Error)
+		} else {
+			assert.True(t, check.Valid, check.Error)
+		}
+	}
+	assert.Len(t, report.Unverifiable(), 5)
+	assert.Empty(t, report.Failures())
+	assert.True(t, report.Valid())
+}
+
+func TestVerifyBlockTampered(t *testing.T) {
+	block, err := getBlock("./mock/sampleblock.pb")
+	assert.NoError(t, err)
+
+	// tamper with the last byte of the transaction payload (it belongs to the signature of the last endorsement):
+	// creator signature and the last endorsement no longer match
+	envelope := &common.Envelope{}
+	assert.NoError(t, proto.Unmarshal(block.Data.Data[0], envelope))
+	envelope.Payload[len(envelope.Payload)-1] ^= 0xff
+	block.Data.Data[0], err = proto.Marshal(envelope)
+	assert.NoError(t, err)
+	// tamper with the header: orderer signature no longer matches
+	block.Header.Number++
+
+	report, err := VerifyBlock(block)
+	assert.NoError(t, err)
+	assert.False(t, report.Valid())
+	failures := report.Failures()
+	assert.Len(t, failures, 3)
+	assert.Equal(t, SignatureBlock, failures[0].Type)
+	assert.Equal(t, SignatureCreator, failures[1].Type)
+	assert.Equal(t, "ECDSA signature is invalid", failures[1].Error)
+	assert.Equal(t, SignatureEndorsement, failures[2].Type)
+	assert.Equal(t, "Org2MSP", failures[2].MSPID)
+}
+
+func TestVerifySignatureInvalidCert(t *testing.T) {
+	assert.Error(t, VerifySignature([]byte("not a cert"), nil, nil))
+}
+
+func TestVerifySignatureP384(t *testing.T) {
+	key, cert := newECDSACert(t, elliptic.P384(), 1)
+	message := []byte("message")
+	signature := signECDSA(t, key, message)
+	assert.NoError(t, VerifySignature(cert, signature, message))
+
+	// high-S signatures are refused as Fabric does
+	ecdsaSignature := struct{ R, S *big.Int }{}
+	_, err := asn1.Unmarshal(signature, &ecdsaSignature)
+	assert.NoError(t, err)
+	highS, err := asn1.Marshal(struct{ R, S *big.Int }{ecdsaSignature.R, new(big.Int).Sub(key.Params().N, ecdsaSignature.S)})
+	assert.NoError(t, err)
+	assert.EqualError(t, VerifySignature(cert, highS, message), "ECDSA signature is not low-S")
+
+	// Fabric hashes with SHA256 whatever the curve
+	digest := sha512.Sum384(message)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	if s.Cmp(new(big.Int).Rsh(key.Params().N, 1)) > 0 {
+		s.Sub(key.Params().N, s)
+	}
+	sha384Signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	assert.NoError(t, err)
+	assert.EqualError(t, VerifySignature(cert, sha384Signature, message), "ECDSA signature is invalid")
+}
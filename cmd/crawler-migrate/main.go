@@ -0,0 +1,50 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Command crawler-migrate rewrites Badger storage of the crawler offline: blocks saved by the previous versions
+// are moved into the namespaces of their channels and all stored values are re-encoded with the codec
+// and the current schema version. Stop the crawler before the migration.
+// Storages with custom upgrades (storageadapter.Migrations) are migrated by a program which calls
+// storageadapter.MigrateStorage with storageadapter.WithMigrations.
+package main
+
+import (
+	"flag"
+	"github.com/newity/crawler/storage"
+	"github.com/newity/crawler/storageadapter"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	path := flag.String("path", "", "path to Badger storage directory")
+	codecName := flag.String("codec", "gob", "codec to re-encode values with: gob, json or proto")
+	flag.Parse()
+
+	if *path == "" {
+		logrus.Fatal("storage path is not set")
+	}
+	codecs := map[string]storageadapter.Codec{
+		"gob":   storageadapter.GobCodec{},
+		"json":  storageadapter.JSONCodec{},
+		"proto": storageadapter.ProtoCodec{},
+	}
+	codec, ok := codecs[*codecName]
+	if !ok {
+		logrus.Fatalf("unknown codec %s", *codecName)
+	}
+
+	badger, err := storage.NewBadger(*path)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	migrated, err := storageadapter.MigrateStorage(badger, storageadapter.WithCodec(codec))
+	if closeErr := badger.Close(); closeErr != nil {
+		logrus.Errorf("failed to close storage: %s", closeErr)
+	}
+	if err != nil {
+		logrus.Fatalf("failed to migrate storage (%d values rewritten): %s", migrated, err)
+	}
+	logrus.Infof("storage migrated, %d values rewritten", migrated)
+}
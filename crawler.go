@@ -29,6 +29,8 @@ SPDX-License-Identifier: Apache-2.0
 package crawler
 
 import (
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
@@ -37,12 +39,14 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/newity/crawler/blocklib"
 	"github.com/newity/crawler/parser"
 	"github.com/newity/crawler/storage"
 	"github.com/newity/crawler/storageadapter"
 	"github.com/sirupsen/logrus"
 	"os"
 	"path"
+	"time"
 )
 
 // Crawler is responsible for fetching info from blockchain
@@ -57,6 +61,8 @@ type Crawler struct {
 	adapter         storageadapter.StorageAdapter
 	storage         storage.Storage
 	configProvider  core.ConfigProvider
+	verification    VerificationMode
+	resolver        blocklib.ConfigBlockResolver
 }
 
 // New creates Crawler instance from HLF connection profile and returns pointer to it.
@@ -199,29 +205,148 @@ func (c *Crawler) StopListenAll() {
 // Run starts parsing blocks and saves them to storage.
 // The parsing strategy is determined by the implementation of the parser.
 // What and in what form will be stored in the storage is determined by the storage adapter implementation.
+//
+// If the parser implements parser.RecordParser and the storage adapter implements storageadapter.RecordAdapter,
+// blocks are additionally split into records (one per tx, event and state write) and records are saved along with parser.Data.
+//
+// If signature verification is enabled (see WithSignatureVerification), blocks are verified before parsing.
 func (c *Crawler) Run() {
+	recordParser, isRecordParser := c.parser.(parser.RecordParser)
+	recordAdapter, isRecordAdapter := c.adapter.(storageadapter.RecordAdapter)
+
 	for _, notifier := range c.notifiers {
 		for blockevent := range notifier {
+			report, ok := c.verify(blockevent.Block)
+			if !ok {
+				continue
+			}
+
 			data, err := c.parser.Parse(blockevent.Block)
 			if err != nil {
 				logrus.Error(err)
 				continue
 			}
-			if data == nil {
-				continue
+			if data != nil {
+				data.Verification = report
+				if err = c.adapter.Inject(data); err != nil {
+					// records of the block are not saved without the block
+					logrus.Error(err)
+					continue
+				}
 			}
-			if err = c.adapter.Inject(data); err != nil {
-				logrus.Error(err)
+
+			if isRecordParser && isRecordAdapter {
+				records, err := recordParser.ParseRecords(blockevent.Block)
+				if err != nil {
+					logrus.Error(err)
+					continue
+				}
+				if err = recordAdapter.InjectRecords(records); err != nil {
+					logrus.Error(err)
+				}
 			}
 		}
 	}
 }
 
-// GetBlock retrieves specified data from a storage by specified key and returns it in the form of parser.Data.
+// verify checks signatures of the block according to the verification mode.
+// It returns false if the block must be skipped.
+func (c *Crawler) verify(block *common.Block) (*blocklib.VerificationReport, bool) {
+	if c.verification == VERIFY_NONE {
+		return nil, true
+	}
+	var (
+		report *blocklib.VerificationReport
+		err    error
+	)
+	if c.resolver != nil {
+		report, err = blocklib.VerifyBlockWithResolver(c.resolver, block)
+	} else {
+		report, err = blocklib.VerifyBlock(block)
+	}
+	if err != nil {
+		logrus.Errorf("failed to verify block %d: %s", block.Header.Number, err)
+		return nil, c.verification != VERIFY_REJECT
+	}
+	for _, failure := range report.Failures() {
+		logrus.Warnf("block %d: %s signature of %s (tx %d, action %d, index %d) failed verification: %s",
+			report.BlockNumber, failure.Type, failure.MSPID, failure.TxIndex, failure.ActionIndex, failure.Index, failure.Error)
+	}
+	for _, indicator := range report.TamperIndicators {
+		logrus.Warnf("block %d: tx %d (%s) action %d is possibly tampered: %s mismatch (expected %s, actual %s) %s",
+			report.BlockNumber, indicator.TxIndex, indicator.TxId, indicator.ActionIndex, indicator.Type, indicator.Expected, indicator.Actual, indicator.Error)
+	}
+	if report.Quorum != nil && !report.Quorum.Satisfied() {
+		logrus.Warnf("block %d is signed by %d of %d consenters, quorum is %d",
+			report.BlockNumber, len(report.Quorum.Signers), report.Quorum.Consenters, report.Quorum.Quorum)
+	}
+	if !report.Valid() && c.verification == VERIFY_REJECT {
+		logrus.Errorf("block %d is rejected: verification failed", report.BlockNumber)
+		return report, false
+	}
+	return report, true
+}
+
+// Adapter returns the storage adapter of the crawler. Storage adapters which support queries beyond blocks
+// implement interfaces of storageadapter (IndexAdapter, HistoryAdapter, WorldStateAdapter and others), type-assert the adapter to query them:
+//
+//	indexAdapter, ok := engine.Adapter().(storageadapter.IndexAdapter)
+//	if ok {
+//		tx, location, err := indexAdapter.Tx("mychannel", txID)
+//	}
+func (c *Crawler) Adapter() storageadapter.StorageAdapter {
+	return c.adapter
+}
+
+// GetFromStorage retrieves specified data from a storage by specified key and returns it in the form of parser.Data.
+// Blocks saved by SimpleAdapter are retrieved by key "<channel>/<blocknum>", bare block number is found only in the storage
+// with blocks of a single channel (or saved by the previous versions).
 func (c *Crawler) GetFromStorage(key string) (*parser.Data, error) {
 	return c.adapter.Retrieve(key)
 }
 
+// GetBlockFromStorage retrieves parser.Data of the block of the channel if the storage adapter keeps blocks by channel.
+func (c *Crawler) GetBlockFromStorage(channel string, number uint64) (*parser.Data, error) {
+	blockAdapter, ok := c.adapter.(storageadapter.BlockAdapter)
+	if !ok {
+		return nil, fmt.Errorf("storage adapter %T does not support retrieval by channel", c.adapter)
+	}
+	return blockAdapter.RetrieveBlock(channel, number)
+}
+
+// GetRecordFromStorage retrieves record by its key (parser.RecordID.Key, the channel for QueueAdapter) if the storage adapter supports records.
+func (c *Crawler) GetRecordFromStorage(key string) (*parser.Record, error) {
+	recordAdapter, ok := c.adapter.(storageadapter.RecordAdapter)
+	if !ok {
+		return nil, fmt.Errorf("storage adapter %T does not support records", c.adapter)
+	}
+	return recordAdapter.RetrieveRecord(key)
+}
+
+// GetRollupsFromStorage retrieves throughput statistics rollups of the channel with granularity (parser.GranularityMinute, parser.GranularityHour
+// or parser.GranularityDay) for the periods between from and to if the storage adapter supports rollups.
+func (c *Crawler) GetRollupsFromStorage(channel, granularity string, from, to time.Time) ([]*parser.Rollup, error) {
+	rollupAdapter, ok := c.adapter.(storageadapter.RollupAdapter)
+	if !ok {
+		return nil, fmt.Errorf("storage adapter %T does not support rollups", c.adapter)
+	}
+	return rollupAdapter.Rollups(channel, granularity, from, to)
+}
+
 func (c *Crawler) ReadStreamFromStorage(key string) (<-chan *parser.Data, <-chan error) {
 	return c.adapter.ReadStream(key)
 }
+
+// ReadRangeFromStorage streams parser.Data of the blocks of the channel from 'from' to 'to' (inclusive)
+// if the storage adapter keeps blocks by channel.
+func (c *Crawler) ReadRangeFromStorage(channel string, from, to uint64) (<-chan *parser.Data, <-chan error) {
+	blockAdapter, ok := c.adapter.(storageadapter.BlockAdapter)
+	if !ok {
+		errChan := make(chan error, 1)
+		errChan <- fmt.Errorf("storage adapter %T does not support retrieval by channel", c.adapter)
+		out := make(chan *parser.Data)
+		close(out)
+		return out, errChan
+	}
+	return blockAdapter.ReadRange(channel, from, to)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crawler
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func getBlock(t *testing.T, path string) *common.Block {
+	raw, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	block := &common.Block{}
+	assert.NoError(t, proto.Unmarshal(raw, block))
+	return block
+}
+
+func TestVerifyReject(t *testing.T) {
+	c := &Crawler{verification: VERIFY_REJECT}
+
+	// orderer signatures of BFT blocks are unverifiable without the config block, the block is not rejected
+	report, ok := c.verify(getBlock(t, "./blocklib/mock/withevents.pb"))
+	assert.True(t, ok)
+	assert.True(t, report.Valid())
+	assert.Len(t, report.Unverifiable(), 5)
+
+	block := getBlock(t, "./blocklib/mock/sampleblock.pb")
+	last := len(block.Data.Data[0]) - 1
+	block.Data.Data[0][last] ^= 0xff
+	report, ok = c.verify(block)
+	assert.False(t, ok)
+	assert.False(t, report.Valid())
+
+	// with the resolver BFT blocks are verified against the consenters, the block is rejected if they can't be found
+	c.resolver = blocklib.ConfigBlockResolverFunc(func(channel string, number uint64) (*common.Block, error) {
+		return nil, fmt.Errorf("config block %d of channel %s is not found", number, channel)
+	})
+	_, ok = c.verify(getBlock(t, "./blocklib/mock/withevents.pb"))
+	assert.False(t, ok)
+	_, ok = c.verify(getBlock(t, "./blocklib/mock/sampleblock.pb"))
+	assert.True(t, ok)
+}
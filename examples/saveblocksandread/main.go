@@ -14,7 +14,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"os"
 	"path"
-	"strconv"
 	"time"
 )
 
@@ -51,7 +50,7 @@ func main() {
 }
 
 func readBlock(engine *crawler.Crawler, num int) {
-	data, err := engine.GetFromStorage(strconv.Itoa(num))
+	data, err := engine.GetBlockFromStorage(CHANNEL, uint64(num))
 	if err != nil {
 		logrus.Error(err)
 	}
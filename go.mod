@@ -10,6 +10,8 @@ require (
 	github.com/golang/protobuf v1.4.3
 	github.com/hyperledger/fabric-protos-go v0.0.0-20200707132912-fee30f3ccd23
 	github.com/hyperledger/fabric-sdk-go v1.0.0-beta3
+	github.com/lib/pq v1.8.0
+	github.com/mattn/go-sqlite3 v1.14.5
 	github.com/nats-io/nats-streaming-server v0.19.0 // indirect
 	github.com/nats-io/stan.go v0.7.0
 	github.com/onsi/ginkgo v1.7.0 // indirect
@@ -18,4 +20,5 @@ require (
 	github.com/sirupsen/logrus v1.7.0
 	github.com/stretchr/testify v1.5.1
 	google.golang.org/api v0.34.0
+	google.golang.org/protobuf v1.25.0
 )
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/newity/crawler/blocklib"
 	"github.com/newity/crawler/parser"
 	"github.com/newity/crawler/storage"
 	"github.com/newity/crawler/storageadapter"
@@ -70,6 +71,39 @@ func WithStorageAdapter(a storageadapter.StorageAdapter) Option {
 	}
 }
 
+// VerificationMode defines what the crawler does with blocks whose signatures fail cryptographic verification.
+type VerificationMode string
+
+const (
+	VERIFY_NONE   VerificationMode = ""       // signatures are not verified
+	VERIFY_FLAG   VerificationMode = "flag"   // blocks are parsed and stored as usual, verification report is added to parser.Data
+	VERIFY_REJECT VerificationMode = "reject" // blocks with failed signatures or tamper indicators are skipped
+)
+
+// WithSignatureVerification makes the crawler verify orderer, creator and endorsement signatures of every block before parsing.
+// See VerificationMode for the ways failed blocks are handled.
+func WithSignatureVerification(mode VerificationMode) Option {
+	return func(crawler *Crawler) error {
+		switch mode {
+		case VERIFY_NONE, VERIFY_FLAG, VERIFY_REJECT:
+			crawler.verification = mode
+			return nil
+		default:
+			return fmt.Errorf("unknown verification mode %s", mode)
+		}
+	}
+}
+
+// WithConfigBlockResolver sets the resolver of config blocks used by signature verification (see WithSignatureVerification).
+// Orderer signatures of BFT blocks are verified against consenters of the last config block got from resolver
+// and the blocks must be signed by the quorum of consenters. Without the resolver these signatures are reported as unverifiable.
+func WithConfigBlockResolver(resolver blocklib.ConfigBlockResolver) Option {
+	return func(crawler *Crawler) error {
+		crawler.resolver = resolver
+		return nil
+	}
+}
+
 type ListenOpt func() interface{}
 
 const (
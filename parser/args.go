@@ -0,0 +1,232 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Types of chaincode function arguments
+const (
+	ArgString = "string" // value is string
+	ArgInt    = "int"    // value is int64
+	ArgJSON   = "json"   // value is a result of json.Unmarshal into interface{}
+	ArgBase64 = "base64" // value is decoded []byte
+	ArgProto  = "proto"  // value is a protobuf message in the JSON-friendly form (map[string]interface{})
+	ArgRaw    = "raw"    // value is string, no schema is registered for the argument
+)
+
+// ArgSchema describes a chaincode function argument.
+type ArgSchema struct {
+	Name    string
+	Type    string
+	Message string // fully-qualified name of the protobuf message for ArgProto type, e.g. common.Block
+}
+
+// Arg is a named and typed chaincode function argument.
+type Arg struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// Invocation is a chaincode function call decoded according to the registered schema.
+type Invocation struct {
+	TxId        string
+	ActionIndex int
+	Chaincode   string
+	Function    string
+	Args        []Arg
+	Raw         bool // true if no schema is registered for the function, all args are passed as is
+}
+
+// ArgsRegistry holds argument schemas of chaincode functions.
+type ArgsRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]map[string][]ArgSchema // chaincode => function => args
+	decoder *blocklib.ProtoDecoder
+}
+
+func NewArgsRegistry() *ArgsRegistry {
+	return &ArgsRegistry{schemas: make(map[string]map[string][]ArgSchema)}
+}
+
+// Register sets argument schemas for the function of the chaincode (function name is not included in args).
+func (r *ArgsRegistry) Register(chaincode, function string, args ...ArgSchema) *ArgsRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.schemas[chaincode] == nil {
+		r.schemas[chaincode] = make(map[string][]ArgSchema)
+	}
+	r.schemas[chaincode][function] = args
+	return r
+}
+
+// WithProtoDecoder makes the registry look up ArgProto messages in the descriptor sets loaded to decoder
+// (by default only messages compiled into the binary are available).
+func (r *ArgsRegistry) WithProtoDecoder(decoder *blocklib.ProtoDecoder) *ArgsRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoder = decoder
+	return r
+}
+
+// Schema returns argument schemas of the function of the chaincode.
+func (r *ArgsRegistry) Schema(chaincode, function string) ([]ArgSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[chaincode][function]
+	return schema, ok
+}
+
+// Decode converts chaincode input (function name followed by args, as returned by blocklib.Action.ChaincodeInput) into Invocation.
+// Args of the unknown functions and args beyond the schema are passed as raw strings.
+func (r *ArgsRegistry) Decode(chaincode string, input []string) (*Invocation, error) {
+	invocation := &Invocation{Chaincode: chaincode}
+	if len(input) == 0 {
+		return invocation, nil
+	}
+	invocation.Function = input[0]
+
+	schema, ok := r.Schema(chaincode, invocation.Function)
+	invocation.Raw = !ok
+	for i, raw := range input[1:] {
+		if i >= len(schema) {
+			invocation.Args = append(invocation.Args, Arg{Type: ArgRaw, Value: raw})
+			continue
+		}
+		value, err := r.decodeArg(schema[i], raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode argument %d (%s) of %s.%s: %w", i, schema[i].Name, chaincode, invocation.Function, err)
+		}
+		invocation.Args = append(invocation.Args, Arg{Name: schema[i].Name, Type: schema[i].Type, Value: value})
+	}
+	return invocation, nil
+}
+
+// RawInvocation returns Invocation with all args passed as is.
+func RawInvocation(chaincode string, input []string) *Invocation {
+	invocation := &Invocation{Chaincode: chaincode, Raw: true}
+	if len(input) == 0 {
+		return invocation
+	}
+	invocation.Function = input[0]
+	for _, raw := range input[1:] {
+		invocation.Args = append(invocation.Args, Arg{Type: ArgRaw, Value: raw})
+	}
+	return invocation
+}
+
+func (r *ArgsRegistry) decodeArg(schema ArgSchema, raw string) (interface{}, error) {
+	switch schema.Type {
+	case ArgString, ArgRaw, "":
+		return raw, nil
+	case ArgInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case ArgJSON:
+		var value interface{}
+		err := json.Unmarshal([]byte(raw), &value)
+		return value, err
+	case ArgBase64:
+		return base64.StdEncoding.DecodeString(raw)
+	case ArgProto:
+		r.mu.RLock()
+		decoder := r.decoder
+		r.mu.RUnlock()
+		if decoder != nil {
+			return decoder.Decode(schema.Message, []byte(raw))
+		}
+		return decodeProtoMessage(schema.Message, []byte(raw))
+	default:
+		return nil, fmt.Errorf("unknown argument type %s", schema.Type)
+	}
+}
+
+// decodeProtoMessage unmarshals protobuf message registered by generated code and converts it to JSON-friendly form.
+func decodeProtoMessage(name string, raw []byte) (interface{}, error) {
+	messageType := proto.MessageType(name)
+	if messageType == nil {
+		return nil, fmt.Errorf("unknown protobuf message %s", name)
+	}
+	message := reflect.New(messageType.Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(raw, message); err != nil {
+		return nil, err
+	}
+	return protoToJSONFriendly(message)
+}
+
+func protoToJSONFriendly(message proto.Message) (interface{}, error) {
+	marshaled, err := (&jsonpb.Marshaler{OrigName: true}).MarshalToString(message)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal([]byte(marshaled), &value)
+	return value, err
+}
+
+// ArgsParser works as ParserImpl and additionally decodes chaincode invocations of each transaction into named and typed arguments.
+type ArgsParser struct {
+	*ParserImpl
+	registry *ArgsRegistry
+}
+
+func NewArgsParser(registry *ArgsRegistry) *ArgsParser {
+	return &ArgsParser{ParserImpl: New(), registry: registry}
+}
+
+func (p *ArgsParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range data.Txs {
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction ID: %s", err)
+			continue
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to actions from transaction: %s", err)
+			continue
+		}
+		for i, action := range actions {
+			ccAction, err := action.ChaincodeAction()
+			if err != nil {
+				logrus.Errorf("failed to get to ChaincodeAction: %s", err)
+				continue
+			}
+			input, err := action.ChaincodeInput()
+			if err != nil {
+				logrus.Errorf("failed to get chaincode input: %s", err)
+				continue
+			}
+
+			chaincode := ccAction.GetChaincodeId().GetName()
+			invocation, err := p.registry.Decode(chaincode, input)
+			if err != nil {
+				logrus.Errorf("failed to decode chaincode input: %s", err)
+				invocation = RawInvocation(chaincode, input)
+			}
+			invocation.TxId = txID
+			invocation.ActionIndex = i
+			data.Invocations = append(data.Invocations, *invocation)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"encoding/base64"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func getBlock(pathToBlock string) (*common.Block, error) {
+	file, err := ioutil.ReadFile(pathToBlock)
+	if err != nil {
+		return nil, err
+	}
+	fabBlock := &common.Block{}
+	err = proto.Unmarshal(file, fabBlock)
+	return fabBlock, err
+}
+
+func TestArgsRegistryDecode(t *testing.T) {
+	header, err := proto.Marshal(&common.BlockHeader{Number: 10})
+	assert.NoError(t, err)
+
+	registry := NewArgsRegistry().Register("cc", "transfer",
+		ArgSchema{Name: "to", Type: ArgString},
+		ArgSchema{Name: "amount", Type: ArgInt},
+		ArgSchema{Name: "meta", Type: ArgJSON},
+		ArgSchema{Name: "data", Type: ArgBase64},
+		ArgSchema{Name: "header", Type: ArgProto, Message: "common.BlockHeader"},
+	)
+
+	t.Run("known function", func(t *testing.T) {
+		invocation, err := registry.Decode("cc", []string{
+			"transfer", "bob", "42", `{"ref":"x"}`, base64.StdEncoding.EncodeToString([]byte{1, 2}), string(header), "extra",
+		})
+		assert.NoError(t, err)
+		assert.False(t, invocation.Raw)
+		assert.Equal(t, "transfer", invocation.Function)
+		assert.Equal(t, []Arg{
+			{Name: "to", Type: ArgString, Value: "bob"},
+			{Name: "amount", Type: ArgInt, Value: int64(42)},
+			{Name: "meta", Type: ArgJSON, Value: map[string]interface{}{"ref": "x"}},
+			{Name: "data", Type: ArgBase64, Value: []byte{1, 2}},
+			{Name: "header", Type: ArgProto, Value: map[string]interface{}{"number": "10"}},
+			{Type: ArgRaw, Value: "extra"},
+		}, invocation.Args)
+	})
+
+	t.Run("unknown function", func(t *testing.T) {
+		invocation, err := registry.Decode("cc", []string{"burn", "10"})
+		assert.NoError(t, err)
+		assert.True(t, invocation.Raw)
+		assert.Equal(t, []Arg{{Type: ArgRaw, Value: "10"}}, invocation.Args)
+	})
+
+	t.Run("invalid argument", func(t *testing.T) {
+		_, err := registry.Decode("cc", []string{"transfer", "bob", "forty two"})
+		assert.Error(t, err)
+	})
+}
+
+func TestArgsParser(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, err)
+
+	registry := NewArgsRegistry().Register("fabcar", "createCar",
+		ArgSchema{Name: "key", Type: ArgString},
+		ArgSchema{Name: "make", Type: ArgString},
+		ArgSchema{Name: "model", Type: ArgString},
+		ArgSchema{Name: "colour", Type: ArgString},
+		ArgSchema{Name: "owner", Type: ArgString},
+	)
+
+	data, err := NewArgsParser(registry).Parse(block)
+	assert.NoError(t, err)
+	assert.Len(t, data.Invocations, 1)
+
+	invocation := data.Invocations[0]
+	assert.Equal(t, "23e7c409b6849a71e6b5d7767a4e6c7efcd4bafba02b932ca5e6559e4d050dea", invocation.TxId)
+	assert.Equal(t, "fabcar", invocation.Chaincode)
+	assert.Equal(t, "createCar", invocation.Function)
+	assert.Equal(t, Arg{Name: "owner", Type: ArgString, Value: "Mary"}, invocation.Args[4])
+}
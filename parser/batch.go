@@ -0,0 +1,234 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// DefaultHistoryLimit is the number of the latest records of each channel the parsers keep in memory (e.g. BatchParser.Cuts).
+// The full history is saved by the storage adapter along with the blocks (in parser.Data).
+const DefaultHistoryLimit = 1000
+
+// BlockCutRecord describes how the block of the channel was cut by the orderer.
+type BlockCutRecord struct {
+	blocklib.BlockCut
+	Channel     string
+	BlockNumber uint64
+}
+
+// BatchStats contains statistics of block cutting in the channel. Config blocks are counted in Cuts only.
+type BatchStats struct {
+	Blocks       int            // number of the blocks with transactions (excluding config blocks)
+	Cuts         map[string]int // cut reason => number of blocks
+	AvgMessages  float64
+	AvgBytes     float64
+	AvgFillRatio float64
+	MinFillRatio float64
+	MaxFillRatio float64
+	AvgLatency   time.Duration // average time from the transactions to the commit of their blocks (see blocklib.BlockCut)
+	MaxLatency   time.Duration
+}
+
+// TimeoutShare returns the share of the blocks cut by timeout. The high share with the low fill ratio means
+// that the batch timeout limits throughput (or the batch size is too big for the load), the low share means the batch size does.
+func (s *BatchStats) TimeoutShare() float64 {
+	if s.Blocks == 0 {
+		return 0
+	}
+	return float64(s.Cuts[blocklib.CutTimeout]) / float64(s.Blocks)
+}
+
+// BatchParser works as ParserImpl and additionally classifies each block as cut by max message count, preferred bytes or timeout
+// using BatchSize and BatchTimeout of the config in force and collects statistics of block fill ratio and latency of each channel.
+// The parser remembers batch config of the last config block, so blocks must be passed to it in order.
+// The classification is added to Data.BlockCut. Only the latest cuts are kept in memory (see WithHistoryLimit),
+// statistics of the cuts saved before the restart are restored with WithCuts.
+type BatchParser struct {
+	*ParserImpl
+	mu      sync.RWMutex
+	limit   int
+	configs map[string]*blocklib.BatchConfig // channel => batch config
+	cuts    map[string][]BlockCutRecord      // channel => latest cuts
+	stats   map[string]*batchTotals          // channel => totals
+}
+
+type batchTotals struct {
+	blocks     int
+	cuts       map[string]int
+	messages   int
+	bytes      int
+	fillRatio  float64
+	minFill    float64
+	maxFill    float64
+	latency    time.Duration // sum of latencies of the transactions
+	maxLatency time.Duration
+}
+
+func NewBatchParser() *BatchParser {
+	return &BatchParser{
+		ParserImpl: New(),
+		limit:      DefaultHistoryLimit,
+		configs:    make(map[string]*blocklib.BatchConfig),
+		cuts:       make(map[string][]BlockCutRecord),
+		stats:      make(map[string]*batchTotals),
+	}
+}
+
+// WithConfig sets batch config from the config in force for the channel (e.g. if crawling starts from the middle of the chain).
+func (p *BatchParser) WithConfig(channel string, config *common.Config) error {
+	batch, err := blocklib.OrdererBatchConfig(config)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs[channel] = batch
+	return nil
+}
+
+// WithHistoryLimit sets the number of the latest cuts of each channel kept in memory, 0 means no limit.
+func (p *BatchParser) WithHistoryLimit(limit int) *BatchParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = limit
+	return p
+}
+
+// WithCuts adds cuts of the blocks parsed before (e.g. Data.BlockCut of the blocks saved before the restart) to the history and statistics.
+func (p *BatchParser) WithCuts(records ...BlockCutRecord) *BatchParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, record := range records {
+		p.add(record)
+	}
+	return p
+}
+
+func (p *BatchParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+	b, err := blocklib.FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.IsConfig() {
+		config, err := blocklib.ConfigFromBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.WithConfig(data.Channel, config); err != nil {
+			logrus.Errorf("failed to get batch config: %s", err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	batch, ok := p.configs[data.Channel]
+	if !ok {
+		return data, nil
+	}
+	cut, err := b.ClassifyCut(batch)
+	if err != nil {
+		logrus.Errorf("failed to classify block cut: %s", err)
+		return data, nil
+	}
+
+	record := BlockCutRecord{BlockCut: *cut, Channel: data.Channel, BlockNumber: data.BlockNumber}
+	p.add(record)
+	data.BlockCut = &record
+	return data, nil
+}
+
+func (p *BatchParser) add(record BlockCutRecord) {
+	cuts := append(p.cuts[record.Channel], record)
+	if p.limit > 0 && len(cuts) > p.limit {
+		cuts = cuts[len(cuts)-p.limit:]
+	}
+	p.cuts[record.Channel] = cuts
+
+	totals, ok := p.stats[record.Channel]
+	if !ok {
+		totals = &batchTotals{cuts: make(map[string]int)}
+		p.stats[record.Channel] = totals
+	}
+	cut := record.BlockCut
+	totals.cuts[cut.Reason]++
+	if cut.Reason == blocklib.CutConfig {
+		return
+	}
+
+	if totals.blocks == 0 || cut.FillRatio < totals.minFill {
+		totals.minFill = cut.FillRatio
+	}
+	if cut.FillRatio > totals.maxFill {
+		totals.maxFill = cut.FillRatio
+	}
+	if cut.MaxLatency > totals.maxLatency {
+		totals.maxLatency = cut.MaxLatency
+	}
+	totals.blocks++
+	totals.messages += cut.Messages
+	totals.bytes += cut.Bytes
+	totals.fillRatio += cut.FillRatio
+	totals.latency += cut.Latency * time.Duration(cut.Messages)
+}
+
+// BatchConfig returns batch config in force for the channel.
+func (p *BatchParser) BatchConfig(channel string) (*blocklib.BatchConfig, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	batch, ok := p.configs[channel]
+	if !ok {
+		return nil, false
+	}
+	copied := *batch
+	return &copied, true
+}
+
+// Cuts returns cuts of the latest blocks of the channel seen by the parser (since its batch config is known).
+func (p *BatchParser) Cuts(channel string) []BlockCutRecord {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]BlockCutRecord{}, p.cuts[channel]...)
+}
+
+// Stats returns block cutting statistics of all the blocks of the channel seen by the parser (and added with WithCuts).
+func (p *BatchParser) Stats(channel string) BatchStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	stats := BatchStats{Cuts: make(map[string]int)}
+	totals, ok := p.stats[channel]
+	if !ok {
+		return stats
+	}
+	for reason, count := range totals.cuts {
+		stats.Cuts[reason] = count
+	}
+	if totals.blocks == 0 {
+		return stats
+	}
+
+	n := float64(totals.blocks)
+	stats.Blocks = totals.blocks
+	stats.AvgMessages = float64(totals.messages) / n
+	stats.AvgBytes = float64(totals.bytes) / n
+	stats.AvgFillRatio = totals.fillRatio / n
+	stats.MinFillRatio = totals.minFill
+	stats.MaxFillRatio = totals.maxFill
+	if totals.messages > 0 {
+		stats.AvgLatency = totals.latency / time.Duration(totals.messages)
+	}
+	stats.MaxLatency = totals.maxLatency
+	return stats
+}
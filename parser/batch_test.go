@@ -0,0 +1,64 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/newity/crawler/blocklib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBatchParser(t *testing.T) {
+	batchParser := NewBatchParser()
+
+	// batch config is unknown until the first config block
+	block, err := getBlock("../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, err)
+	data, err := batchParser.Parse(block)
+	assert.NoError(t, err)
+	assert.Nil(t, data.BlockCut)
+
+	for _, path := range []string{"../blocklib/mock/config.pb", "../blocklib/mock/sampleblock.pb", "../blocklib/mock/mvcc_read_conflict.pb"} {
+		block, err := getBlock(path)
+		assert.NoError(t, err)
+		data, err := batchParser.Parse(block)
+		assert.NoError(t, err)
+		assert.NotNil(t, data.BlockCut)
+	}
+
+	batch, ok := batchParser.BatchConfig("mychannel")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(10), batch.MaxMessageCount)
+	assert.Equal(t, 2*time.Second, batch.Timeout)
+
+	cuts := batchParser.Cuts("mychannel")
+	assert.Len(t, cuts, 3)
+	assert.Equal(t, blocklib.CutConfig, cuts[0].Reason)
+	assert.Equal(t, uint64(7), cuts[1].BlockNumber)
+	assert.Equal(t, blocklib.CutTimeout, cuts[1].Reason)
+	assert.Equal(t, 1, cuts[1].Messages)
+	assert.Equal(t, 5, cuts[2].Messages)
+
+	stats := batchParser.Stats("mychannel")
+	assert.Equal(t, 2, stats.Blocks)
+	assert.Equal(t, map[string]int{blocklib.CutConfig: 1, blocklib.CutTimeout: 2}, stats.Cuts)
+	assert.Equal(t, 3.0, stats.AvgMessages)
+	assert.InDelta(t, 0.3, stats.AvgFillRatio, 1e-9)
+	assert.InDelta(t, 0.1, stats.MinFillRatio, 1e-9)
+	assert.InDelta(t, 0.5, stats.MaxFillRatio, 1e-9)
+	// latencies of the transactions of both blocks are averaged
+	assert.Equal(t, (cuts[1].Latency+5*cuts[2].Latency)/6, stats.AvgLatency)
+	assert.Equal(t, cuts[2].MaxLatency, stats.MaxLatency)
+	assert.Equal(t, 1.0, stats.TimeoutShare())
+
+	assert.Equal(t, 0, batchParser.Stats("otherchannel").Blocks)
+
+	// only the latest cuts are kept in memory, statistics cover all of them
+	limited := NewBatchParser().WithHistoryLimit(2).WithCuts(cuts...)
+	assert.Equal(t, cuts[1:], limited.Cuts("mychannel"))
+	assert.Equal(t, stats, limited.Stats("mychannel"))
+}
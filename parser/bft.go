@@ -0,0 +1,166 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// BFTView is the SmartBFT view in which the block was decided.
+type BFTView struct {
+	Channel         string
+	BlockNumber     uint64
+	Timestamp       time.Time
+	ViewId          uint64
+	Sequence        uint64
+	DecisionsInView uint64
+	BlackList       []uint64 // IDs of the consenters excluded from leadership
+	Signers         []uint64 // IDs of the consenters with valid signatures of the block
+	PreviousViewId  uint64   // view of the previous block seen, equals ViewId for the first block seen
+}
+
+// BFTParser works as ParserImpl and additionally attributes block signatures of SmartBFT channels to consenters,
+// verifies that the block is signed by the quorum of consenters and tracks view changes of each channel.
+// Config blocks passed to the parser are cached, the rest are got from resolver (if any),
+// so crawling from the genesis block needs no resolver.
+// Quorum report is added to Data.BFTQuorum, the view of the block is added to Data.BFTView.
+// Only the latest view changes are kept in memory (see WithHistoryLimit), the changes saved before the restart are restored with WithViewChanges.
+type BFTParser struct {
+	*ParserImpl
+	resolver *blocklib.CachingConfigResolver
+	mu       sync.RWMutex
+	limit    int
+	changes  map[string][]BFTView // channel => latest view changes
+}
+
+// NewBFTParser creates BFTParser, resolver of the config blocks not passed to the parser may be nil.
+func NewBFTParser(resolver blocklib.ConfigBlockResolver) *BFTParser {
+	return &BFTParser{
+		ParserImpl: New(),
+		resolver:   blocklib.NewCachingConfigResolver(resolver),
+		limit:      DefaultHistoryLimit,
+		changes:    make(map[string][]BFTView),
+	}
+}
+
+// WithConfigBlock adds config block in force for the channel (e.g. if crawling starts from the middle of the chain).
+func (p *BFTParser) WithConfigBlock(channel string, block *common.Block) {
+	p.resolver.Put(channel, block)
+}
+
+// WithHistoryLimit sets the number of the latest view changes of each channel kept in memory, 0 means no limit.
+func (p *BFTParser) WithHistoryLimit(limit int) *BFTParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = limit
+	return p
+}
+
+// WithViewChanges adds views seen before (e.g. Data.BFTView of the blocks saved before the restart) to the history.
+// Views must be passed in the order of the blocks, views which don't change the previous one are skipped.
+func (p *BFTParser) WithViewChanges(views ...BFTView) *BFTParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, view := range views {
+		p.add(view)
+	}
+	return p
+}
+
+func (p *BFTParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+	b, err := blocklib.FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	if b.IsConfig() {
+		p.resolver.Put(data.Channel, block)
+	}
+
+	bftBlock, err := blocklib.FromBFTFabricBlockWithResolver(p.resolver, block)
+	if err != nil {
+		logrus.Errorf("failed to attribute signatures of block %d to BFT consenters: %s", data.BlockNumber, err)
+		return data, nil
+	}
+	data.BlockSignatures = bftBlock.OrderersSignatures()
+	if data.BFTQuorum, err = blocklib.VerifyBFTQuorum(p.resolver, block); err != nil {
+		logrus.Errorf("failed to verify BFT quorum: %s", err)
+		return data, nil
+	}
+	if !data.BFTQuorum.Satisfied() {
+		logrus.Errorf("block %d of channel %s is signed by %d consenters of %d, quorum is %d",
+			data.BlockNumber, data.Channel, len(data.BFTQuorum.Signers), data.BFTQuorum.Consenters, data.BFTQuorum.Quorum)
+	}
+
+	viewMetadata, err := b.ViewMetadata()
+	if err != nil {
+		logrus.Errorf("failed to get view metadata: %s", err)
+		return data, nil
+	}
+	view := BFTView{
+		Channel:         data.Channel,
+		BlockNumber:     data.BlockNumber,
+		ViewId:          viewMetadata.ViewId,
+		Sequence:        viewMetadata.LatestSequence,
+		DecisionsInView: viewMetadata.DecisionsInView,
+		BlackList:       viewMetadata.BlackList,
+		Signers:         data.BFTQuorum.Signers,
+		PreviousViewId:  viewMetadata.ViewId,
+	}
+	if txs, err := b.Txs(); err == nil && len(txs) > 0 {
+		if view.Timestamp, err = txs[0].Timestamp(); err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if changes := p.changes[data.Channel]; len(changes) > 0 {
+		view.PreviousViewId = changes[len(changes)-1].ViewId
+	}
+	p.add(view)
+	data.BFTView = &view
+	return data, nil
+}
+
+// add appends the view to the history of the channel if the view has changed.
+func (p *BFTParser) add(view BFTView) {
+	changes := p.changes[view.Channel]
+	if len(changes) > 0 && changes[len(changes)-1].ViewId == view.ViewId {
+		return
+	}
+	changes = append(changes, view)
+	if p.limit > 0 && len(changes) > p.limit {
+		changes = changes[len(changes)-p.limit:]
+	}
+	p.changes[view.Channel] = changes
+}
+
+// View returns the current view of the channel (as of the first block decided in it).
+func (p *BFTParser) View(channel string) (*BFTView, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	changes := p.changes[channel]
+	if len(changes) == 0 {
+		return nil, false
+	}
+	view := changes[len(changes)-1]
+	return &view, true
+}
+
+// ViewChanges returns the latest view changes of the channel: the first block decided in each new view and the consenters which signed it.
+func (p *BFTParser) ViewChanges(channel string) []BFTView {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]BFTView{}, p.changes[channel]...)
+}
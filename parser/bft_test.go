@@ -0,0 +1,111 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/newity/crawler/blocklib"
+	"github.com/newity/crawler/blocklib/smartbft"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBFTParser(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/withevents.pb")
+	assert.NoError(t, err)
+
+	bftParser := NewBFTParser(nil)
+	bftParser.WithConfigBlock("cc", newSmartBFTConfigBlock(t, "cc", 4))
+	data, err := bftParser.Parse(block)
+	assert.NoError(t, err)
+
+	// signatures are attributed to consenters, but consenters identities are fake
+	assert.Len(t, data.BlockSignatures, 5)
+	assert.Equal(t, uint64(4), data.BlockSignatures[0].ConsenterId)
+	assert.Equal(t, "Orderer4MSP", data.BlockSignatures[0].MSPID)
+	assert.NotNil(t, data.BFTQuorum)
+	assert.Equal(t, 7, data.BFTQuorum.Consenters)
+	assert.Equal(t, 5, data.BFTQuorum.Quorum)
+	assert.False(t, data.BFTQuorum.Satisfied())
+
+	assert.NotNil(t, data.BFTView)
+	assert.Equal(t, uint64(0), data.BFTView.ViewId)
+	assert.Equal(t, uint64(64), data.BFTView.Sequence)
+	assert.Equal(t, "cc", data.BFTView.Channel)
+
+	changes := bftParser.ViewChanges("cc")
+	assert.Len(t, changes, 1)
+	assert.Equal(t, uint64(64), changes[0].BlockNumber)
+	view, ok := bftParser.View("cc")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(64), view.BlockNumber)
+
+	_, ok = bftParser.View("mychannel")
+	assert.False(t, ok)
+
+	// view changes of the restarted crawler are restored from the saved views, only the latest are kept
+	assert.Equal(t, changes, NewBFTParser(nil).WithViewChanges(*data.BFTView).ViewChanges("cc"))
+	next := *data.BFTView
+	next.ViewId, next.BlockNumber = 1, 65
+	limited := NewBFTParser(nil).WithHistoryLimit(1).WithViewChanges(*data.BFTView, *data.BFTView, next)
+	assert.Equal(t, []BFTView{next}, limited.ViewChanges("cc"))
+}
+
+func TestBFTParserNoConfig(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/withevents.pb")
+	assert.NoError(t, err)
+
+	data, err := NewBFTParser(nil).Parse(block)
+	assert.NoError(t, err)
+	assert.Nil(t, data.BFTQuorum)
+	assert.Nil(t, data.BFTView)
+
+	resolver := blocklib.ConfigBlockResolverFunc(func(channel string, number uint64) (*common.Block, error) {
+		return newSmartBFTConfigBlock(t, channel, number), nil
+	})
+	data, err = NewBFTParser(resolver).Parse(block)
+	assert.NoError(t, err)
+	assert.NotNil(t, data.BFTQuorum)
+	assert.NotNil(t, data.BFTView)
+}
+
+// newSmartBFTConfigBlock makes config block of the channel from config.pb replacing its consensus type with smartbft
+// with 7 consenters with fake identities.
+func newSmartBFTConfigBlock(t *testing.T, channel string, number uint64) *common.Block {
+	block, err := getBlock("../blocklib/mock/config.pb")
+	assert.NoError(t, err)
+
+	metadata := &smartbft.ConfigMetadata{}
+	for id := uint64(1); id <= 7; id++ {
+		identity, err := proto.Marshal(&msp.SerializedIdentity{Mspid: fmt.Sprintf("Orderer%dMSP", id), IdBytes: []byte(fmt.Sprintf("cert%d", id))})
+		assert.NoError(t, err)
+		metadata.Consenters = append(metadata.Consenters, &smartbft.Consenter{ConsenterId: id, Identity: identity})
+	}
+	metadataBytes, err := proto.Marshal(metadata)
+	assert.NoError(t, err)
+	consensusType, err := proto.Marshal(&orderer.ConsensusType{Type: blocklib.ConsensusBFT, Metadata: metadataBytes})
+	assert.NoError(t, err)
+
+	envelope := &common.Envelope{}
+	assert.NoError(t, proto.Unmarshal(block.Data.Data[0], envelope))
+	payload := &common.Payload{}
+	assert.NoError(t, proto.Unmarshal(envelope.Payload, payload))
+	configEnvelope := &common.ConfigEnvelope{}
+	assert.NoError(t, proto.Unmarshal(payload.Data, configEnvelope))
+	configEnvelope.Config.ChannelGroup.Groups["Orderer"].Values["ConsensusType"].Value = consensusType
+	payload.Data, err = proto.Marshal(configEnvelope)
+	assert.NoError(t, err)
+	envelope.Payload, err = proto.Marshal(payload)
+	assert.NoError(t, err)
+	block.Data.Data[0], err = proto.Marshal(envelope)
+	assert.NoError(t, err)
+	block.Header.Number = number
+	return block
+}
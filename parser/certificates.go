@@ -0,0 +1,89 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// CertificateParser works as ParserImpl and additionally validates certificates of the orderers, tx creators and endorsers
+// against MSP root and intermediate certificates and revocation lists of the config in force for the block (see blocklib.Block.LastConfig).
+// The parser remembers configs of all config blocks it has parsed, so config blocks must be passed to it before the blocks that refer to them.
+// Results are added to Data.Certificates.
+type CertificateParser struct {
+	*ParserImpl
+	mu         sync.RWMutex
+	validators map[string]map[uint64]*blocklib.MSPValidator // channel => config block number => validator
+}
+
+func NewCertificateParser() *CertificateParser {
+	return &CertificateParser{
+		ParserImpl: New(),
+		validators: make(map[string]map[uint64]*blocklib.MSPValidator),
+	}
+}
+
+// WithConfig sets the config of config block with number 'number' of the channel (e.g. if crawling starts from the middle of the chain).
+func (p *CertificateParser) WithConfig(channel string, number uint64, config *common.Config) error {
+	validator, err := blocklib.NewMSPValidator(config)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setValidator(channel, number, validator)
+	return nil
+}
+
+func (p *CertificateParser) setValidator(channel string, number uint64, validator *blocklib.MSPValidator) {
+	if p.validators[channel] == nil {
+		p.validators[channel] = make(map[uint64]*blocklib.MSPValidator)
+	}
+	p.validators[channel][number] = validator
+}
+
+func (p *CertificateParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+	b, err := blocklib.FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.IsConfig() {
+		config, err := blocklib.ConfigFromBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.WithConfig(data.Channel, b.Number(), config); err != nil {
+			logrus.Errorf("failed to create MSP validator from config block %d: %s", b.Number(), err)
+			return data, nil
+		}
+	}
+
+	lastConfig, err := b.LastConfig()
+	if err != nil {
+		logrus.Errorf("failed to get last config index: %s", err)
+		return data, nil
+	}
+	p.mu.RLock()
+	validator, ok := p.validators[data.Channel][lastConfig]
+	p.mu.RUnlock()
+	if !ok {
+		logrus.Errorf("config block %d of channel %s is unknown, certificates of block %d are not validated", lastConfig, data.Channel, b.Number())
+		return data, nil
+	}
+
+	if data.Certificates, err = validator.ValidateBlock(block); err != nil {
+		logrus.Errorf("failed to validate certificates: %s", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCertificateParser(t *testing.T) {
+	certificateParser := NewCertificateParser()
+
+	// block 7 refers to config block 2, which is not parsed yet
+	block7, err := getBlock("../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, err)
+	data, err := certificateParser.Parse(block7)
+	assert.NoError(t, err)
+	assert.Empty(t, data.Certificates)
+
+	// config block is validated against its own config
+	block2, err := getBlock("../blocklib/mock/configUpdate.pb")
+	assert.NoError(t, err)
+	data, err = certificateParser.Parse(block2)
+	assert.NoError(t, err)
+	assert.Len(t, data.Certificates, 2)
+	for _, check := range data.Certificates {
+		assert.True(t, check.Valid, check.Error)
+	}
+
+	// mock block 7 comes from another deployment of 'mychannel', so its signers are unknown to config block 2
+	data, err = certificateParser.Parse(block7)
+	assert.NoError(t, err)
+	assert.Len(t, data.Certificates, 4)
+	for _, check := range data.Certificates {
+		assert.False(t, check.Valid)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// ConfigUpdateParser works as ParserImpl and additionally attributes config updates of configuration blocks to the admins who signed them.
+// The parser remembers the last config of each channel, so blocks must be passed to it in order.
+// Until the parser sees the first config block of the channel, config updates are attributed without mod_policy checks.
+type ConfigUpdateParser struct {
+	*ParserImpl
+	mu      sync.Mutex
+	configs map[string]*common.Config // channel => config in force
+}
+
+func NewConfigUpdateParser() *ConfigUpdateParser {
+	return &ConfigUpdateParser{
+		ParserImpl: New(),
+		configs:    make(map[string]*common.Config),
+	}
+}
+
+// WithConfig sets the config in force for the channel (e.g. if crawling starts from the middle of the chain).
+func (p *ConfigUpdateParser) WithConfig(channel string, config *common.Config) *ConfigUpdateParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs[channel] = config
+	return p
+}
+
+func (p *ConfigUpdateParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := blocklib.FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsConfig() {
+		return data, nil
+	}
+
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+	configEnvelope, err := txs[0].ConfigEnvelope()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if configEnvelope.LastUpdate != nil {
+		if previous, ok := p.configs[data.Channel]; ok {
+			data.ConfigUpdate, err = txs[0].ConfigUpdateAttribution(previous)
+		} else {
+			var signatures []blocklib.ConfigSignature
+			signatures, err = txs[0].ConfigUpdateSignatures()
+			data.ConfigUpdate = &blocklib.ConfigUpdateAttribution{Signatures: signatures}
+		}
+		if err != nil {
+			logrus.Errorf("failed to attribute config update: %s", err)
+		}
+	}
+	p.configs[data.Channel] = configEnvelope.Config
+	return data, nil
+}
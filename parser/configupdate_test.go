@@ -0,0 +1,65 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/newity/crawler/blocklib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfigUpdateParser(t *testing.T) {
+	configUpdateParser := NewConfigUpdateParser()
+
+	block, err := getBlock("../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, err)
+	data, err := configUpdateParser.Parse(block)
+	assert.NoError(t, err)
+	assert.Nil(t, data.ConfigUpdate)
+
+	// the first config block is attributed without mod_policy checks
+	block, err = getBlock("../blocklib/mock/forIntegrityCheck.pb")
+	assert.NoError(t, err)
+	data, err = configUpdateParser.Parse(block)
+	assert.NoError(t, err)
+	assert.NotNil(t, data.ConfigUpdate)
+	assert.NotEmpty(t, data.ConfigUpdate.Signatures)
+	assert.Empty(t, data.ConfigUpdate.Modifications)
+
+	// the next ones are checked against the config in force
+	block, err = getBlock("../blocklib/mock/configUpdate.pb")
+	assert.NoError(t, err)
+	data, err = configUpdateParser.Parse(block)
+	assert.NoError(t, err)
+	assert.Equal(t, []blocklib.ConfigModification{{
+		Path:      "/Channel/Application/Org2MSP",
+		Kind:      blocklib.ConfigElementGroup,
+		Version:   1,
+		ModPolicy: "/Channel/Application/Org2MSP/Admins",
+		Satisfied: true,
+	}}, data.ConfigUpdate.Modifications)
+
+	block, err = getBlock("../blocklib/mock/config.pb")
+	assert.NoError(t, err)
+	data, err = configUpdateParser.Parse(block)
+	assert.NoError(t, err)
+	assert.Len(t, data.ConfigUpdate.Signatures, 2)
+	assert.True(t, data.ConfigUpdate.Satisfied())
+
+	// config in force can be set when crawling starts from the middle of the chain
+	previous, err := getBlock("../blocklib/mock/configUpdate.pb")
+	assert.NoError(t, err)
+	b, err := blocklib.FromFabricBlock(previous)
+	assert.NoError(t, err)
+	txs, err := b.Txs()
+	assert.NoError(t, err)
+	configEnvelope, err := txs[0].ConfigEnvelope()
+	assert.NoError(t, err)
+	data, err = NewConfigUpdateParser().WithConfig("mychannel", configEnvelope.Config).Parse(block)
+	assert.NoError(t, err)
+	assert.Len(t, data.ConfigUpdate.Modifications, 1)
+	assert.True(t, data.ConfigUpdate.Satisfied())
+}
@@ -0,0 +1,157 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// EndorsementCoverage is endorsement policy coverage of the transaction action.
+type EndorsementCoverage struct {
+	blocklib.EndorsementCoverage
+	TxId           string
+	ActionIndex    int
+	ValidationCode int32
+}
+
+// EndorsementParser works as ParserImpl and additionally checks which organizations endorsed each action of endorser transactions
+// and whether they satisfy the chaincode endorsement policy (from the chaincode definition) or state-based endorsement policies of the written keys.
+// The parser tracks channel config, chaincode definitions and key-level policies of valid transactions, so blocks must be passed to it in order.
+// Results are added to Data.Endorsements.
+type EndorsementParser struct {
+	*ParserImpl
+	mu          sync.RWMutex
+	configs     map[string]*common.Config                                        // channel => config in force
+	policies    map[string]map[string]*blocklib.EndorsementPolicy                // channel => chaincode => policy
+	keyPolicies map[string]map[string]map[string]*common.SignaturePolicyEnvelope // channel => namespace => key => policy
+}
+
+func NewEndorsementParser() *EndorsementParser {
+	return &EndorsementParser{
+		ParserImpl:  New(),
+		configs:     make(map[string]*common.Config),
+		policies:    make(map[string]map[string]*blocklib.EndorsementPolicy),
+		keyPolicies: make(map[string]map[string]map[string]*common.SignaturePolicyEnvelope),
+	}
+}
+
+// WithConfig sets the config in force for the channel (e.g. if crawling starts from the middle of the chain).
+func (p *EndorsementParser) WithConfig(channel string, config *common.Config) *EndorsementParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs[channel] = config
+	return p
+}
+
+// WithChaincodePolicy sets endorsement policy of the chaincode in the channel (e.g. if the chaincode was defined before the first parsed block).
+func (p *EndorsementParser) WithChaincodePolicy(channel, chaincode string, policy *blocklib.EndorsementPolicy) *EndorsementParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setPolicy(channel, chaincode, policy)
+	return p
+}
+
+func (p *EndorsementParser) setPolicy(channel, chaincode string, policy *blocklib.EndorsementPolicy) {
+	if p.policies[channel] == nil {
+		p.policies[channel] = make(map[string]*blocklib.EndorsementPolicy)
+	}
+	p.policies[channel][chaincode] = policy
+}
+
+func (p *EndorsementParser) setKeyPolicy(channel, namespace, key string, policy *common.SignaturePolicyEnvelope) {
+	if p.keyPolicies[channel] == nil {
+		p.keyPolicies[channel] = make(map[string]map[string]*common.SignaturePolicyEnvelope)
+	}
+	if p.keyPolicies[channel][namespace] == nil {
+		p.keyPolicies[channel][namespace] = make(map[string]*common.SignaturePolicyEnvelope)
+	}
+	if policy == nil {
+		delete(p.keyPolicies[channel][namespace], key)
+		return
+	}
+	p.keyPolicies[channel][namespace][key] = policy
+}
+
+func (p *EndorsementParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(data.Txs) == 0 {
+		if config, err := blocklib.ConfigFromBlock(block); err == nil {
+			p.configs[data.Channel] = config
+		}
+		return data, nil
+	}
+
+	config := p.configs[data.Channel]
+	chaincodePolicies := func(namespace string) *blocklib.EndorsementPolicy {
+		if policy, ok := p.policies[data.Channel][namespace]; ok {
+			return policy
+		}
+		if namespace == blocklib.LifecycleNamespace {
+			return &blocklib.EndorsementPolicy{ChannelConfigPolicy: blocklib.DefaultLifecycleEndorsementPolicy}
+		}
+		return nil
+	}
+	keyPolicies := func(namespace, key string) *common.SignaturePolicyEnvelope {
+		return p.keyPolicies[data.Channel][namespace][key]
+	}
+
+	for _, tx := range data.Txs {
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction ID: %s", err)
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to actions from transaction: %s", err)
+			continue
+		}
+		for i, action := range actions {
+			coverage, err := action.EndorsementCoverage(chaincodePolicies, keyPolicies, config)
+			if err != nil {
+				logrus.Errorf("failed to evaluate endorsement policy coverage: %s", err)
+				continue
+			}
+			data.Endorsements = append(data.Endorsements, EndorsementCoverage{
+				EndorsementCoverage: *coverage,
+				TxId:                txID,
+				ActionIndex:         i,
+				ValidationCode:      tx.ValidationCode(),
+			})
+
+			// only valid transactions change chaincode and key-level policies
+			if !tx.IsValid() {
+				continue
+			}
+			definition, err := action.ChaincodeDefinition()
+			if err != nil {
+				logrus.Errorf("failed to decode chaincode definition: %s", err)
+			} else if definition != nil && definition.Operation != blocklib.LifecycleApprove {
+				p.setPolicy(data.Channel, definition.Name, definition.ChaincodeEndorsementPolicy())
+			}
+			keyLevel, err := action.KeyLevelPolicies()
+			if err != nil {
+				logrus.Errorf("failed to decode key-level endorsement policies: %s", err)
+				continue
+			}
+			for namespace, keys := range keyLevel {
+				for key, policy := range keys {
+					p.setKeyPolicy(data.Channel, namespace, key, policy)
+				}
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/newity/crawler/blocklib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEndorsementParser(t *testing.T) {
+	endorsementParser := NewEndorsementParser()
+
+	// fabcar invocation before the chaincode definition is known
+	sampleBlock, err := getBlock("../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, err)
+	data, err := endorsementParser.Parse(sampleBlock)
+	assert.NoError(t, err)
+	assert.Len(t, data.Endorsements, 1)
+	assert.False(t, data.Endorsements[0].Satisfied())
+
+	// LSCC deploy of fabcar with 2-of(Org1MSP, Org2MSP) policy
+	genesis, err := getBlock("../blocklib/mock/genesis.pb")
+	assert.NoError(t, err)
+	_, err = endorsementParser.Parse(genesis)
+	assert.NoError(t, err)
+
+	data, err = endorsementParser.Parse(sampleBlock)
+	assert.NoError(t, err)
+	assert.Len(t, data.Endorsements, 1)
+	coverage := data.Endorsements[0]
+	assert.Equal(t, "fabcar", coverage.Chaincode)
+	assert.Equal(t, []string{"Org1MSP", "Org2MSP"}, coverage.EndorsingOrgs)
+	assert.Equal(t, blocklib.PolicySourceChaincode, coverage.Policies[0].Source)
+	assert.True(t, coverage.Satisfied())
+	assert.NotEmpty(t, coverage.TxId)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ways the identity can be seen in the chain
+const (
+	SeenAsCreator  = "creator"
+	SeenAsEndorser = "endorser"
+	SeenAsOrderer  = "orderer"
+)
+
+// IdentityRecord is an identity seen in the channel with the info about when it was seen first and last.
+type IdentityRecord struct {
+	blocklib.Identity
+	Channel        string
+	SeenAs         []string // creator, endorser and/or orderer
+	FirstSeenBlock uint64
+	FirstSeen      time.Time
+	LastSeenBlock  uint64
+	LastSeen       time.Time
+}
+
+// IdentityParser works as ParserImpl and additionally extracts identities of the tx creators, endorsers and orderers.
+// Identities are deduplicated by certificate fingerprint and kept in the registry of each channel.
+// Identities seen in the block are added to Data.Identities. The registry holds one record per distinct identity (certificate),
+// the registry saved before the restart (Data.Identities of the saved blocks) is restored with WithIdentities.
+type IdentityParser struct {
+	*ParserImpl
+	mu         sync.RWMutex
+	identities map[string]map[string]*IdentityRecord // channel => fingerprint => identity
+}
+
+func NewIdentityParser() *IdentityParser {
+	return &IdentityParser{
+		ParserImpl: New(),
+		identities: make(map[string]map[string]*IdentityRecord),
+	}
+}
+
+// WithIdentities adds identities seen before (e.g. Data.Identities of the blocks saved before the restart) to the registry.
+func (p *IdentityParser) WithIdentities(records ...IdentityRecord) *IdentityParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, record := range records {
+		p.merge(record)
+	}
+	return p
+}
+
+func (p *IdentityParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]*IdentityRecord)
+	see := func(identity *blocklib.Identity, seenAs string, timestamp time.Time) {
+		record, ok := seen[identity.Fingerprint]
+		if !ok {
+			record = &IdentityRecord{
+				Identity:       *identity,
+				Channel:        data.Channel,
+				FirstSeenBlock: data.BlockNumber,
+				FirstSeen:      timestamp,
+				LastSeenBlock:  data.BlockNumber,
+				LastSeen:       timestamp,
+			}
+			seen[identity.Fingerprint] = record
+		}
+		record.SeenAs = mergeStrings(record.SeenAs, []string{seenAs})
+		if !timestamp.IsZero() && (record.FirstSeen.IsZero() || timestamp.Before(record.FirstSeen)) {
+			record.FirstSeen = timestamp
+		}
+		if timestamp.After(record.LastSeen) {
+			record.LastSeen = timestamp
+		}
+	}
+
+	// the config transaction of config blocks dates the block and its creator is seen
+	txs := data.Txs
+	if len(txs) == 0 && data.ConfigTx != nil {
+		txs = []blocklib.Tx{*data.ConfigTx}
+	}
+	var blockTime time.Time
+	for _, tx := range txs {
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		}
+		if timestamp.After(blockTime) {
+			blockTime = timestamp
+		}
+
+		if creator, err := tx.CreatorIdentity(); err != nil {
+			logrus.Errorf("failed to get transaction creator identity: %s", err)
+		} else {
+			see(creator, SeenAsCreator, timestamp)
+		}
+
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to actions from transaction: %s", err)
+			continue
+		}
+		for _, action := range actions {
+			endorsers, err := action.EndorserIdentities()
+			if err != nil {
+				logrus.Errorf("failed to get endorser identities: %s", err)
+				continue
+			}
+			for _, endorser := range endorsers {
+				see(endorser, SeenAsEndorser, timestamp)
+			}
+		}
+	}
+
+	for _, signature := range data.BlockSignatures {
+		orderer, err := signature.Identity()
+		if err != nil {
+			logrus.Errorf("failed to get orderer identity: %s", err)
+			continue
+		}
+		see(orderer, SeenAsOrderer, blockTime)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, record := range seen {
+		data.Identities = append(data.Identities, *record)
+		p.merge(*record)
+	}
+	sort.Slice(data.Identities, func(i, j int) bool {
+		return data.Identities[i].Fingerprint < data.Identities[j].Fingerprint
+	})
+	return data, nil
+}
+
+// merge adds the identity to the registry or extends the known one with the blocks it is seen in.
+// Unknown (zero) times don't replace the known ones.
+func (p *IdentityParser) merge(record IdentityRecord) {
+	if p.identities[record.Channel] == nil {
+		p.identities[record.Channel] = make(map[string]*IdentityRecord)
+	}
+	known, ok := p.identities[record.Channel][record.Fingerprint]
+	if !ok {
+		p.identities[record.Channel][record.Fingerprint] = &record
+		return
+	}
+	known.SeenAs = mergeStrings(known.SeenAs, record.SeenAs)
+	if record.FirstSeenBlock < known.FirstSeenBlock {
+		known.FirstSeenBlock = record.FirstSeenBlock
+		if !record.FirstSeen.IsZero() {
+			known.FirstSeen = record.FirstSeen
+		}
+	}
+	if record.LastSeenBlock >= known.LastSeenBlock {
+		known.LastSeenBlock = record.LastSeenBlock
+		if !record.LastSeen.IsZero() {
+			known.LastSeen = record.LastSeen
+		}
+	}
+}
+
+// Identities returns all identities seen in the channel.
+func (p *IdentityParser) Identities(channel string) []IdentityRecord {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var records []IdentityRecord
+	for _, record := range p.identities[channel] {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].FirstSeenBlock != records[j].FirstSeenBlock {
+			return records[i].FirstSeenBlock < records[j].FirstSeenBlock
+		}
+		return records[i].Fingerprint < records[j].Fingerprint
+	})
+	return records
+}
+
+// Identity returns identity seen in the channel by its certificate fingerprint.
+func (p *IdentityParser) Identity(channel, fingerprint string) (*IdentityRecord, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	record, ok := p.identities[channel][fingerprint]
+	if !ok {
+		return nil, false
+	}
+	copied := *record
+	return &copied, true
+}
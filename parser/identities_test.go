@@ -0,0 +1,88 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIdentityParser(t *testing.T) {
+	identityParser := NewIdentityParser()
+	var saved []IdentityRecord
+
+	// the network was redeployed between blocks 7 and 35 of 'mychannel', so all certificates differ
+	for _, path := range []string{"../blocklib/mock/sampleblock.pb", "../blocklib/mock/mvcc_read_conflict.pb"} {
+		block, err := getBlock(path)
+		assert.NoError(t, err)
+		data, err := identityParser.Parse(block)
+		assert.NoError(t, err)
+		assert.Len(t, data.Identities, 4)
+		saved = append(saved, data.Identities...)
+	}
+
+	identities := identityParser.Identities("mychannel")
+	assert.Len(t, identities, 8)
+	for i, identity := range identities {
+		expected := uint64(7)
+		if i >= 4 {
+			expected = 35
+		}
+		assert.Equal(t, expected, identity.FirstSeenBlock)
+		assert.Equal(t, expected, identity.LastSeenBlock)
+		assert.False(t, identity.LastSeen.Before(identity.FirstSeen))
+	}
+
+	creator, ok := identityParser.Identity("mychannel", "22cac6f62054764d8974a4563904e535d13738b02a9f5ffbb5f34e7022dc9ab2")
+	assert.True(t, ok)
+	assert.Equal(t, "user1", creator.CommonName)
+	assert.Equal(t, []string{SeenAsCreator}, creator.SeenAs)
+
+	_, ok = identityParser.Identity("otherchannel", creator.Fingerprint)
+	assert.False(t, ok)
+
+	// the registry of the restarted crawler is restored from the saved identities
+	assert.Equal(t, identities, NewIdentityParser().WithIdentities(saved...).Identities("mychannel"))
+}
+
+func TestIdentityParserConfigBlock(t *testing.T) {
+	identityParser := NewIdentityParser()
+	block, err := getBlock("../blocklib/mock/config.pb")
+	assert.NoError(t, err)
+	data, err := identityParser.Parse(block)
+	assert.NoError(t, err)
+
+	// the creator of the config transaction is seen and the orderers are dated by it
+	var creators, orderers int
+	for _, identity := range data.Identities {
+		assert.False(t, identity.FirstSeen.IsZero(), identity.CommonName)
+		assert.False(t, identity.LastSeen.IsZero(), identity.CommonName)
+		for _, seenAs := range identity.SeenAs {
+			switch seenAs {
+			case SeenAsCreator:
+				creators++
+			case SeenAsOrderer:
+				orderers++
+			}
+		}
+	}
+	assert.Equal(t, 1, creators)
+	assert.True(t, orderers > 0)
+
+	// unknown times don't override the known ones
+	known := data.Identities[0]
+	unknown := known
+	unknown.FirstSeenBlock, unknown.LastSeenBlock = 0, 10
+	unknown.FirstSeen, unknown.LastSeen = time.Time{}, time.Time{}
+	identityParser.WithIdentities(unknown)
+	merged, ok := identityParser.Identity(known.Channel, known.Fingerprint)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), merged.FirstSeenBlock)
+	assert.Equal(t, known.FirstSeen, merged.FirstSeen)
+	assert.Equal(t, uint64(10), merged.LastSeenBlock)
+	assert.Equal(t, known.LastSeen, merged.LastSeen)
+}
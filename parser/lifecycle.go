@@ -0,0 +1,264 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChaincodeDefinitionRecord is a chaincode definition with the info about the transaction which made it.
+type ChaincodeDefinitionRecord struct {
+	blocklib.ChaincodeDefinition
+	Channel      string
+	BlockNumber  uint64
+	TxId         string
+	Timestamp    time.Time
+	CreatorMSPID string
+}
+
+type chaincodeDefinitionRecord ChaincodeDefinitionRecord
+
+// chaincodeDefinitionJSON replaces protobuf messages of the definition with their JSON mapping
+// (encoding/json can't decode oneof fields of the policies).
+type chaincodeDefinitionJSON struct {
+	chaincodeDefinitionRecord
+	EndorsementPolicy json.RawMessage `json:",omitempty"`
+	Collections       json.RawMessage `json:",omitempty"`
+}
+
+// MarshalJSON encodes the record with the endorsement policy and collections in the protobuf JSON mapping.
+func (r ChaincodeDefinitionRecord) MarshalJSON() ([]byte, error) {
+	encoded := chaincodeDefinitionJSON{chaincodeDefinitionRecord: chaincodeDefinitionRecord(r)}
+	marshaler := &jsonpb.Marshaler{OrigName: true}
+	if r.EndorsementPolicy != nil {
+		policy, err := marshaler.MarshalToString(r.EndorsementPolicy)
+		if err != nil {
+			return nil, err
+		}
+		encoded.EndorsementPolicy = json.RawMessage(policy)
+	}
+	if r.Collections != nil {
+		collections, err := marshaler.MarshalToString(r.Collections)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Collections = json.RawMessage(collections)
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes the record encoded by MarshalJSON.
+func (r *ChaincodeDefinitionRecord) UnmarshalJSON(data []byte) error {
+	decoded := chaincodeDefinitionJSON{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = ChaincodeDefinitionRecord(decoded.chaincodeDefinitionRecord)
+	if len(decoded.EndorsementPolicy) > 0 {
+		r.EndorsementPolicy = &common.SignaturePolicyEnvelope{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(decoded.EndorsementPolicy), r.EndorsementPolicy); err != nil {
+			return err
+		}
+	}
+	if len(decoded.Collections) > 0 {
+		r.Collections = &peer.CollectionConfigPackage{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(decoded.Collections), r.Collections); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LifecycleParser works as ParserImpl and additionally decodes chaincode lifecycle operations
+// (legacy LSCC deploy/upgrade and _lifecycle approve/commit) into chaincode definition history of each channel.
+// Approvals collected from approve transactions are attached to the commit of the same definition (name, sequence and version).
+// Only the latest operations of each chaincode are kept in memory (see WithHistoryLimit),
+// the history saved before the restart (Data.ChaincodeDefinitions) is restored with WithHistory.
+type LifecycleParser struct {
+	*ParserImpl
+	mu        sync.RWMutex
+	limit     int
+	history   map[string]map[string][]ChaincodeDefinitionRecord // channel => chaincode name => latest definitions
+	current   map[string]map[string]ChaincodeDefinitionRecord   // channel => chaincode name => definition in force
+	approvals map[string]map[approvalKey][]string               // channel => definition => MSP IDs of the approving orgs
+}
+
+type approvalKey struct {
+	name     string
+	sequence int64
+	version  string
+}
+
+func NewLifecycleParser() *LifecycleParser {
+	return &LifecycleParser{
+		ParserImpl: New(),
+		limit:      DefaultHistoryLimit,
+		history:    make(map[string]map[string][]ChaincodeDefinitionRecord),
+		current:    make(map[string]map[string]ChaincodeDefinitionRecord),
+		approvals:  make(map[string]map[approvalKey][]string),
+	}
+}
+
+// WithHistoryLimit sets the number of the latest operations of each chaincode kept in memory, 0 means no limit.
+func (p *LifecycleParser) WithHistoryLimit(limit int) *LifecycleParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = limit
+	return p
+}
+
+// WithHistory adds lifecycle operations parsed before (e.g. Data.ChaincodeDefinitions of the blocks saved before the restart)
+// to the history. Records must be passed in the order they were committed.
+func (p *LifecycleParser) WithHistory(records ...ChaincodeDefinitionRecord) *LifecycleParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range records {
+		record := records[i]
+		p.apply(&record)
+	}
+	return p
+}
+
+func (p *LifecycleParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, tx := range data.Txs {
+		if !tx.IsValid() {
+			continue
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to actions from transaction: %s", err)
+			continue
+		}
+		for _, action := range actions {
+			definition, err := action.ChaincodeDefinition()
+			if err != nil {
+				logrus.Errorf("failed to decode chaincode definition: %s", err)
+				continue
+			}
+			if definition == nil {
+				continue
+			}
+
+			record := ChaincodeDefinitionRecord{
+				ChaincodeDefinition: *definition,
+				Channel:             data.Channel,
+				BlockNumber:         data.BlockNumber,
+			}
+			if record.TxId, err = tx.TxId(); err != nil {
+				logrus.Errorf("failed to get transaction ID: %s", err)
+			}
+			if record.Timestamp, err = tx.Timestamp(); err != nil {
+				logrus.Errorf("failed to get transaction timestamp: %s", err)
+			}
+			if record.CreatorMSPID, err = action.CreatorMSPID(); err != nil {
+				logrus.Errorf("failed to get transaction creator: %s", err)
+			}
+			p.apply(&record)
+			data.ChaincodeDefinitions = append(data.ChaincodeDefinitions, record)
+		}
+	}
+	return data, nil
+}
+
+// apply adds the record to the history and links approvals with commits.
+func (p *LifecycleParser) apply(record *ChaincodeDefinitionRecord) {
+	if p.history[record.Channel] == nil {
+		p.history[record.Channel] = make(map[string][]ChaincodeDefinitionRecord)
+		p.current[record.Channel] = make(map[string]ChaincodeDefinitionRecord)
+		p.approvals[record.Channel] = make(map[approvalKey][]string)
+	}
+
+	key := approvalKey{name: record.Name, sequence: record.Sequence, version: record.Version}
+	switch record.Operation {
+	case blocklib.LifecycleApprove:
+		p.approvals[record.Channel][key] = mergeStrings(p.approvals[record.Channel][key], record.Approvals)
+	case blocklib.LifecycleCommit:
+		if approvals := p.approvals[record.Channel][key]; len(approvals) > 0 {
+			record.Approvals = mergeStrings(record.Approvals, approvals)
+		}
+		// approvals of this and earlier sequences can't be committed anymore
+		for pending := range p.approvals[record.Channel] {
+			if pending.name == record.Name && pending.sequence <= record.Sequence {
+				delete(p.approvals[record.Channel], pending)
+			}
+		}
+	}
+	if record.Operation != blocklib.LifecycleApprove {
+		p.current[record.Channel][record.Name] = *record
+	}
+
+	history := append(p.history[record.Channel][record.Name], *record)
+	if p.limit > 0 && len(history) > p.limit {
+		history = history[len(history)-p.limit:]
+	}
+	p.history[record.Channel][record.Name] = history
+}
+
+// History returns the latest lifecycle operations made with chaincode in channel in the order they were committed.
+func (p *LifecycleParser) History(channel, chaincode string) []ChaincodeDefinitionRecord {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]ChaincodeDefinitionRecord{}, p.history[channel][chaincode]...)
+}
+
+// Current returns the definition of chaincode in force in channel (the last deploy, upgrade or commit).
+func (p *LifecycleParser) Current(channel, chaincode string) (*ChaincodeDefinitionRecord, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	record, ok := p.current[channel][chaincode]
+	if !ok {
+		return nil, false
+	}
+	return &record, true
+}
+
+// Chaincodes returns names of all chaincodes which have lifecycle history in channel.
+func (p *LifecycleParser) Chaincodes(channel string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var names []string
+	for name := range p.history[channel] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mergeStrings returns sorted union of two string sets (as a new slice).
+func mergeStrings(a []string, b []string) []string {
+	result := append([]string{}, a...)
+	for _, item := range b {
+		found := false
+		for _, existing := range result {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, item)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
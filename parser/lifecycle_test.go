@@ -0,0 +1,56 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/newity/crawler/blocklib"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLifecycleParser(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/genesis.pb")
+	assert.NoError(t, err)
+	lifecycleParser := NewLifecycleParser()
+	data, err := lifecycleParser.Parse(block)
+	assert.NoError(t, err)
+	assert.Len(t, data.ChaincodeDefinitions, 1)
+	assert.Equal(t, blocklib.LifecycleDeploy, data.ChaincodeDefinitions[0].Operation)
+	assert.Equal(t, []string{"fabcar"}, lifecycleParser.Chaincodes(data.Channel))
+
+	// the history of the restarted crawler is restored from the saved definitions
+	restored := NewLifecycleParser().WithHistory(data.ChaincodeDefinitions...)
+	current, ok := restored.Current(data.Channel, "fabcar")
+	assert.True(t, ok)
+	assert.Equal(t, "1.0", current.Version)
+
+	record := func(operation string, sequence int64, approvals ...string) ChaincodeDefinitionRecord {
+		return ChaincodeDefinitionRecord{
+			ChaincodeDefinition: blocklib.ChaincodeDefinition{Operation: operation, Name: "cc", Version: "1", Sequence: sequence, Approvals: approvals},
+			Channel:             "mychannel",
+		}
+	}
+	limited := NewLifecycleParser().WithHistoryLimit(2).WithHistory(
+		record(blocklib.LifecycleApprove, 1, "Org1MSP"),
+		record(blocklib.LifecycleApprove, 1, "Org2MSP"),
+		record(blocklib.LifecycleCommit, 1),
+		record(blocklib.LifecycleApprove, 2, "Org1MSP"),
+		record(blocklib.LifecycleApprove, 3, "Org1MSP"),
+	)
+	history := limited.History("mychannel", "cc")
+	assert.Len(t, history, 2)
+	assert.Equal(t, int64(3), history[1].Sequence)
+	// the commit is in force though it is out of the kept history
+	current, ok = limited.Current("mychannel", "cc")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Org1MSP", "Org2MSP"}, current.Approvals)
+
+	// approvals of the committed sequences are dropped
+	limited.WithHistory(record(blocklib.LifecycleCommit, 3))
+	assert.Empty(t, limited.approvals["mychannel"])
+	current, _ = limited.Current("mychannel", "cc")
+	assert.Equal(t, []string{"Org1MSP"}, current.Approvals)
+}
@@ -11,11 +11,26 @@ import (
 )
 
 type Data struct {
-	BlockNumber     uint64
-	Prevhash        []byte
-	Datahash        []byte
-	BlockSignatures []blocklib.BlockSignature
-	Channel         string
-	Txs             []blocklib.Tx
-	Events          []*peer.ChaincodeEvent
+	BlockNumber          uint64
+	Prevhash             []byte
+	Datahash             []byte
+	BlockSignatures      []blocklib.BlockSignature
+	Channel              string
+	Txs                  []blocklib.Tx
+	ConfigTx             *blocklib.Tx // config transaction (config blocks only, Txs are empty)
+	Events               []*peer.ChaincodeEvent
+	ConfigUpdate         *blocklib.ConfigUpdateAttribution // signers of the config update (config blocks only)
+	ChaincodeDefinitions []ChaincodeDefinitionRecord       // chaincode lifecycle operations
+	Invocations          []Invocation                      // chaincode invocations with decoded arguments
+	DecodedValues        []DecodedValue                    // protobuf-encoded state values and event payloads
+	Identities           []IdentityRecord                  // identities of creators, endorsers and orderers seen in the block
+	Verification         *blocklib.VerificationReport      // results of signature verification (if enabled in the crawler)
+	Certificates         []blocklib.CertificateCheck       // results of signer certificates validation against MSPs of the config in force
+	Endorsements         []EndorsementCoverage             // endorsing organizations and endorsement policies coverage of the actions
+	Consenters           []blocklib.RaftConsenter          // etcdraft consenters (config blocks only)
+	RaftLeader           *RaftLeader                       // etcdraft consenter which signed the block
+	BFTQuorum            *blocklib.BFTQuorumReport         // SmartBFT consenters signatures and quorum
+	BFTView              *BFTView                          // SmartBFT view the block was decided in
+	BlockCut             *BlockCutRecord                   // reason the orderer cut the block, its fill ratio and latency
+	BlockStats           *BlockStats                       // throughput statistics of the block
 }
@@ -82,7 +82,7 @@ func (p *ParserImpl) Parse(block *common.Block) (*Data, error) {
 		return nil, err
 	}
 
-	return &Data{
+	data := &Data{
 		BlockNumber:     block.Header.Number,
 		Prevhash:        block.Header.PreviousHash,
 		Datahash:        block.Header.DataHash,
@@ -90,5 +90,9 @@ func (p *ParserImpl) Parse(block *common.Block) (*Data, error) {
 		Channel:         header.ChannelId,
 		Txs:             selectedTransactions,
 		Events:          selectedEvents,
-	}, nil
+	}
+	if b.IsConfig() {
+		data.ConfigTx = &txs[0]
+	}
+	return data, nil
 }
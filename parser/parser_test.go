@@ -0,0 +1,32 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParserConfigTx(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, err)
+	data, err := New().Parse(block)
+	assert.NoError(t, err)
+	assert.Len(t, data.Txs, 1)
+	assert.Nil(t, data.ConfigTx)
+
+	block, err = getBlock("../blocklib/mock/config.pb")
+	assert.NoError(t, err)
+	data, err = New().Parse(block)
+	assert.NoError(t, err)
+	assert.Empty(t, data.Txs)
+	assert.NotNil(t, data.ConfigTx)
+	header, err := data.ConfigTx.ChannelHeader()
+	assert.NoError(t, err)
+	assert.Equal(t, common.HeaderType_CONFIG, common.HeaderType(header.Type))
+	assert.Equal(t, data.Channel, header.ChannelId)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+)
+
+// DecodedValue is a state value or chaincode event payload of the transaction decoded by blocklib.ProtoDecoder.
+type DecodedValue struct {
+	blocklib.DecodedValue
+	TxId        string
+	ActionIndex int
+}
+
+// ProtoParser works as ParserImpl and additionally decodes protobuf-encoded state values and event payloads
+// with message types mapped in blocklib.ProtoDecoder.
+type ProtoParser struct {
+	*ParserImpl
+	decoder *blocklib.ProtoDecoder
+}
+
+func NewProtoParser(decoder *blocklib.ProtoDecoder) *ProtoParser {
+	return &ProtoParser{ParserImpl: New(), decoder: decoder}
+}
+
+func (p *ProtoParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range data.Txs {
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction ID: %s", err)
+			continue
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to actions from transaction: %s", err)
+			continue
+		}
+		for i := range actions {
+			values, err := p.decoder.DecodeAction(&actions[i])
+			if err != nil {
+				logrus.Errorf("failed to decode protobuf values of transaction %s: %s", txID, err)
+				continue
+			}
+			for _, value := range values {
+				data.DecodedValues = append(data.DecodedValues, DecodedValue{DecodedValue: value, TxId: txID, ActionIndex: i})
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// RaftLeader is the etcdraft consenter which signed the block (blocks are cut and signed by the cluster leader).
+type RaftLeader struct {
+	Channel     string
+	BlockNumber uint64
+	Timestamp   time.Time
+	Consenter   blocklib.RaftConsenter
+	Previous    string // endpoint of the previous leader, empty for the first block seen
+}
+
+// RaftParser works as ParserImpl and additionally decodes etcdraft consenters from config blocks
+// and attributes the orderer signature of each block to a consenter to track leader changes of each channel.
+// The parser remembers consenters of the last config block, so blocks must be passed to it in order.
+// Consenters of config blocks are added to Data.Consenters, the leader which signed the block is added to Data.RaftLeader.
+// Only the latest leader changes are kept in memory (see WithHistoryLimit), the changes saved before the restart are restored with WithLeaderChanges.
+type RaftParser struct {
+	*ParserImpl
+	mu         sync.RWMutex
+	limit      int
+	consenters map[string][]blocklib.RaftConsenter // channel => consenters
+	changes    map[string][]RaftLeader             // channel => latest leader changes
+}
+
+func NewRaftParser() *RaftParser {
+	return &RaftParser{
+		ParserImpl: New(),
+		limit:      DefaultHistoryLimit,
+		consenters: make(map[string][]blocklib.RaftConsenter),
+		changes:    make(map[string][]RaftLeader),
+	}
+}
+
+// WithConfig sets consenters from the config in force for the channel (e.g. if crawling starts from the middle of the chain).
+func (p *RaftParser) WithConfig(channel string, config *common.Config) error {
+	consenters, err := blocklib.RaftConsenters(config)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.consenters[channel] = consenters
+	return nil
+}
+
+// WithHistoryLimit sets the number of the latest leader changes of each channel kept in memory, 0 means no limit.
+func (p *RaftParser) WithHistoryLimit(limit int) *RaftParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = limit
+	return p
+}
+
+// WithLeaderChanges adds leader changes seen before (e.g. Data.RaftLeader of the blocks saved before the restart)
+// to the history. Leaders must be passed in the order of the blocks, leaders which don't change the previous one are skipped.
+func (p *RaftParser) WithLeaderChanges(leaders ...RaftLeader) *RaftParser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, leader := range leaders {
+		p.add(leader)
+	}
+	return p
+}
+
+func (p *RaftParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+	b, err := blocklib.FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.IsConfig() {
+		config, err := blocklib.ConfigFromBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.WithConfig(data.Channel, config); err != nil {
+			logrus.Errorf("failed to get raft consenters: %s", err)
+		} else {
+			data.Consenters = p.Consenters(data.Channel)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	consenters, ok := p.consenters[data.Channel]
+	if !ok || len(data.BlockSignatures) == 0 {
+		return data, nil
+	}
+	consenter, ok := blocklib.MatchRaftConsenter(consenters, data.BlockSignatures[0].Cert)
+	if !ok {
+		logrus.Errorf("failed to attribute signature of block %d to raft consenter of channel %s", data.BlockNumber, data.Channel)
+		return data, nil
+	}
+
+	leader := RaftLeader{Channel: data.Channel, BlockNumber: data.BlockNumber, Consenter: *consenter}
+	if txs, err := b.Txs(); err == nil && len(txs) > 0 {
+		if leader.Timestamp, err = txs[0].Timestamp(); err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		}
+	}
+	if changes := p.changes[data.Channel]; len(changes) > 0 {
+		leader.Previous = changes[len(changes)-1].Consenter.Endpoint()
+	}
+	p.add(leader)
+	data.RaftLeader = &leader
+	return data, nil
+}
+
+// add appends the leader to the history of the channel if the leader has changed.
+func (p *RaftParser) add(leader RaftLeader) {
+	changes := p.changes[leader.Channel]
+	if len(changes) > 0 && changes[len(changes)-1].Consenter.Endpoint() == leader.Consenter.Endpoint() {
+		return
+	}
+	changes = append(changes, leader)
+	if p.limit > 0 && len(changes) > p.limit {
+		changes = changes[len(changes)-p.limit:]
+	}
+	p.changes[leader.Channel] = changes
+}
+
+// Consenters returns raft consenters of the channel.
+func (p *RaftParser) Consenters(channel string) []blocklib.RaftConsenter {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]blocklib.RaftConsenter{}, p.consenters[channel]...)
+}
+
+// Leader returns the current leader of the channel (as of the first block it signed).
+func (p *RaftParser) Leader(channel string) (*RaftLeader, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	changes := p.changes[channel]
+	if len(changes) == 0 {
+		return nil, false
+	}
+	leader := changes[len(changes)-1]
+	return &leader, true
+}
+
+// LeaderChanges returns the latest leader changes of the channel: the first block signed by each new leader.
+func (p *RaftParser) LeaderChanges(channel string) []RaftLeader {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]RaftLeader{}, p.changes[channel]...)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRaftParser(t *testing.T) {
+	raftParser := NewRaftParser()
+	var saved []RaftLeader
+	for i, path := range []string{"../blocklib/mock/forIntegrityCheck.pb", "../blocklib/mock/configUpdate.pb", "../blocklib/mock/config.pb"} {
+		block, err := getBlock(path)
+		assert.NoError(t, err)
+		data, err := raftParser.Parse(block)
+		assert.NoError(t, err)
+		assert.Len(t, data.Consenters, 1)
+		assert.NotNil(t, data.RaftLeader)
+		assert.Equal(t, uint64(i+1), data.RaftLeader.BlockNumber)
+		assert.Equal(t, "orderer.example.com:7050", data.RaftLeader.Consenter.Endpoint())
+		saved = append(saved, *data.RaftLeader)
+	}
+
+	// the only consenter has been the leader since block 1
+	changes := raftParser.LeaderChanges("mychannel")
+	assert.Len(t, changes, 1)
+	assert.Equal(t, uint64(1), changes[0].BlockNumber)
+	assert.Empty(t, changes[0].Previous)
+
+	leader, ok := raftParser.Leader("mychannel")
+	assert.True(t, ok)
+	assert.Equal(t, "orderer.example.com", leader.Consenter.Host)
+	assert.Len(t, raftParser.Consenters("mychannel"), 1)
+
+	_, ok = raftParser.Leader("otherchannel")
+	assert.False(t, ok)
+
+	// leader changes of the restarted crawler are restored from the saved leaders, only the latest are kept
+	assert.Equal(t, changes, NewRaftParser().WithLeaderChanges(saved...).LeaderChanges("mychannel"))
+	other := saved[2]
+	other.Consenter.Host = "orderer2.example.com"
+	limited := NewRaftParser().WithHistoryLimit(1).WithLeaderChanges(saved[0], other)
+	assert.Equal(t, []RaftLeader{other}, limited.LeaderChanges("mychannel"))
+}
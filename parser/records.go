@@ -0,0 +1,167 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/newity/crawler/blocklib"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Types of records
+const (
+	RecordTx    = "tx"
+	RecordEvent = "event"
+	RecordWrite = "write"
+)
+
+// RecordParser serves as a contract for parsers which split a block into a stream of records (one per tx, event, state write)
+// in addition to a single Data. If the crawler's parser and storage adapter support records, records are injected along with Data.
+type RecordParser interface {
+	Parser
+	// ParseRecords is responsible for splitting passed block into records
+	ParseRecords(block *common.Block) ([]*Record, error)
+}
+
+// RecordID is a stable identity of the record in the chain.
+type RecordID struct {
+	Channel     string
+	BlockNumber uint64
+	TxIndex     int
+	ActionIndex int // -1 for tx records
+	Type        string
+	Index       int // number of the write within the action (state write records only)
+}
+
+// Key returns string representation of the record identity.
+// Keys are zero-padded, so lexicographical order of keys is the order of records in the chain.
+func (id RecordID) Key() string {
+	key := fmt.Sprintf("%s/%020d/%06d", id.Channel, id.BlockNumber, id.TxIndex)
+	switch id.Type {
+	case RecordEvent:
+		key += fmt.Sprintf("/%06d/%s", id.ActionIndex, id.Type)
+	case RecordWrite:
+		key += fmt.Sprintf("/%06d/%s/%06d", id.ActionIndex, id.Type, id.Index)
+	}
+	return key
+}
+
+// StateWrite is a single write of the chaincode to the world state.
+type StateWrite struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	IsDelete  bool
+}
+
+// Record is a part of the block: transaction, chaincode event or state write.
+// Only one of Tx, Event and Write is set according to the record type.
+type Record struct {
+	ID             RecordID
+	TxId           string
+	ValidationCode int32
+	Timestamp      time.Time
+	Tx             *blocklib.Tx
+	Event          *peer.ChaincodeEvent
+	Write          *StateWrite
+}
+
+// RecordParserImpl is the default RecordParser.
+// It emits one record per transaction, one per chaincode event and one per state write.
+// Parse works the same way as in ParserImpl.
+type RecordParserImpl struct {
+	*ParserImpl
+}
+
+func NewRecordParser() *RecordParserImpl {
+	return &RecordParserImpl{ParserImpl: New()}
+}
+
+func (p *RecordParserImpl) ParseRecords(block *common.Block) ([]*Record, error) {
+	b, err := blocklib.FromFabricBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	if b.IsConfig() {
+		return nil, nil
+	}
+	txs, err := b.Txs()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*Record
+	for txIndex := range txs {
+		tx := &txs[txIndex]
+		header, err := tx.ChannelHeader()
+		if err != nil {
+			return nil, err
+		}
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		}
+		newRecord := func(recordType string, actionIndex, index int) *Record {
+			return &Record{
+				ID: RecordID{
+					Channel:     header.ChannelId,
+					BlockNumber: b.Number(),
+					TxIndex:     txIndex,
+					ActionIndex: actionIndex,
+					Type:        recordType,
+					Index:       index,
+				},
+				TxId:           header.TxId,
+				ValidationCode: tx.ValidationCode(),
+				Timestamp:      timestamp,
+			}
+		}
+
+		txRecord := newRecord(RecordTx, -1, 0)
+		txRecord.Tx = tx
+		records = append(records, txRecord)
+
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to actions from transaction: %s", err)
+			continue
+		}
+		for actionIndex, action := range actions {
+			event, err := action.ChaincodeEvent()
+			if err != nil {
+				logrus.Errorf("failed to extract chaincode events: %s", err)
+			} else if event.EventName != "" {
+				eventRecord := newRecord(RecordEvent, actionIndex, 0)
+				eventRecord.Event = event
+				records = append(records, eventRecord)
+			}
+
+			rwsets, err := action.RWSets()
+			if err != nil {
+				logrus.Errorf("failed to extract rwsets: %s", err)
+				continue
+			}
+			var writeIndex int
+			for _, rwset := range rwsets {
+				for _, write := range rwset.KVRWSet.Writes {
+					writeRecord := newRecord(RecordWrite, actionIndex, writeIndex)
+					writeRecord.Write = &StateWrite{
+						Namespace: rwset.NameSpace,
+						Key:       write.Key,
+						Value:     write.Value,
+						IsDelete:  write.IsDelete,
+					}
+					records = append(records, writeRecord)
+					writeIndex++
+				}
+			}
+		}
+	}
+	return records, nil
+}
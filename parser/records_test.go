@@ -0,0 +1,49 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseRecords(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/withevents.pb")
+	assert.NoError(t, err)
+
+	records, err := NewRecordParser().ParseRecords(block)
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+
+	txID := "ecb9e0eb95fb799210c3f2465d1e02e4ac506cde523c4a1518bdbbae1f07f508"
+
+	assert.Equal(t, RecordID{Channel: "cc", BlockNumber: 64, TxIndex: 0, ActionIndex: -1, Type: RecordTx}, records[0].ID)
+	assert.Equal(t, "cc/00000000000000000064/000000", records[0].ID.Key())
+	assert.Equal(t, txID, records[0].TxId)
+	assert.NotNil(t, records[0].Tx)
+
+	assert.Equal(t, RecordEvent, records[1].ID.Type)
+	assert.Equal(t, "cc/00000000000000000064/000000/000000/event", records[1].ID.Key())
+	assert.Equal(t, "key", records[1].Event.EventName)
+
+	assert.Equal(t, "cc/00000000000000000064/000000/000000/write/000001", records[3].ID.Key())
+	assert.Equal(t, "cc", records[3].Write.Namespace)
+	assert.True(t, records[3].Write.IsDelete)
+
+	for _, record := range records {
+		assert.Equal(t, txID, record.TxId)
+		assert.Equal(t, int32(0), record.ValidationCode)
+	}
+}
+
+func TestParseRecordsConfig(t *testing.T) {
+	block, err := getBlock("../blocklib/mock/config.pb")
+	assert.NoError(t, err)
+
+	records, err := NewRecordParser().ParseRecords(block)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Granularities of the statistics rollups
+const (
+	GranularityMinute = "minute"
+	GranularityHour   = "hour"
+	GranularityDay    = "day"
+)
+
+// Granularities lists all the granularities of the statistics rollups.
+var Granularities = []string{GranularityMinute, GranularityHour, GranularityDay}
+
+// GranularityDuration returns the period of the rollup granularity.
+func GranularityDuration(granularity string) (time.Duration, error) {
+	switch granularity {
+	case GranularityMinute:
+		return time.Minute, nil
+	case GranularityHour:
+		return time.Hour, nil
+	case GranularityDay:
+		return 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("unknown granularity %s", granularity)
+}
+
+// BlockStats contains throughput statistics of the block.
+// Blocks have no timestamps, so the commit time is approximated by the timestamp of the last transaction.
+type BlockStats struct {
+	Channel         string
+	BlockNumber     uint64
+	CommitTime      time.Time
+	Txs             int
+	ValidTxs        int
+	ValidationCodes map[string]int // peer.TxValidationCode name => number of transactions
+	Bytes           int            // size of the block
+	SinceLastBlock  time.Duration  // time since the commit of the previous block, 0 for the first block seen
+}
+
+// Rollup contains throughput statistics of the channel aggregated over the period of the granularity starting at Start (UTC).
+type Rollup struct {
+	Channel         string
+	Granularity     string
+	Start           time.Time
+	Blocks          int
+	Txs             int
+	ValidTxs        int
+	ValidationCodes map[string]int
+	Bytes           int
+	Intervals       int           // number of the blocks with known time since the previous block
+	TotalInterval   time.Duration // sum of the times between blocks
+	FirstBlock      uint64
+	LastBlock       uint64
+}
+
+// NewRollup creates empty rollup of the channel for the period of granularity which contains moment.
+func NewRollup(channel, granularity string, moment time.Time) (*Rollup, error) {
+	period, err := GranularityDuration(granularity)
+	if err != nil {
+		return nil, err
+	}
+	return &Rollup{
+		Channel:         channel,
+		Granularity:     granularity,
+		Start:           moment.UTC().Truncate(period),
+		ValidationCodes: make(map[string]int),
+	}, nil
+}
+
+// Add adds statistics of the block to the rollup.
+func (r *Rollup) Add(stats *BlockStats) {
+	if r.Blocks == 0 || stats.BlockNumber < r.FirstBlock {
+		r.FirstBlock = stats.BlockNumber
+	}
+	if stats.BlockNumber > r.LastBlock {
+		r.LastBlock = stats.BlockNumber
+	}
+	r.Blocks++
+	r.Txs += stats.Txs
+	r.ValidTxs += stats.ValidTxs
+	r.Bytes += stats.Bytes
+	if r.ValidationCodes == nil {
+		r.ValidationCodes = make(map[string]int)
+	}
+	for code, count := range stats.ValidationCodes {
+		r.ValidationCodes[code] += count
+	}
+	if stats.SinceLastBlock > 0 {
+		r.Intervals++
+		r.TotalInterval += stats.SinceLastBlock
+	}
+}
+
+// Period returns the period of the rollup.
+func (r *Rollup) Period() time.Duration {
+	period, _ := GranularityDuration(r.Granularity)
+	return period
+}
+
+// BlocksPerMinute returns average number of blocks per minute over the period of the rollup.
+func (r *Rollup) BlocksPerMinute() float64 {
+	return float64(r.Blocks) / r.Period().Minutes()
+}
+
+// TPS returns average number of transactions per second over the period of the rollup.
+func (r *Rollup) TPS() float64 {
+	return float64(r.Txs) / r.Period().Seconds()
+}
+
+// ValidRatio returns the share of valid transactions.
+func (r *Rollup) ValidRatio() float64 {
+	if r.Txs == 0 {
+		return 0
+	}
+	return float64(r.ValidTxs) / float64(r.Txs)
+}
+
+// AvgBlockSize returns average size of the blocks in bytes.
+func (r *Rollup) AvgBlockSize() float64 {
+	if r.Blocks == 0 {
+		return 0
+	}
+	return float64(r.Bytes) / float64(r.Blocks)
+}
+
+// AvgBlockInterval returns average time between blocks.
+func (r *Rollup) AvgBlockInterval() time.Duration {
+	if r.Intervals == 0 {
+		return 0
+	}
+	return r.TotalInterval / time.Duration(r.Intervals)
+}
+
+// ThroughputParser works as ParserImpl and additionally computes throughput statistics of each block (see BlockStats).
+// The parser remembers commit time of the last block of each channel, so blocks must be passed to it in order.
+// The statistics are added to Data.BlockStats, use storageadapter.StatsAdapter to keep their rollups in storage.
+type ThroughputParser struct {
+	*ParserImpl
+	mu         sync.Mutex
+	lastCommit map[string]time.Time // channel => commit time of the last block
+}
+
+func NewThroughputParser() *ThroughputParser {
+	return &ThroughputParser{
+		ParserImpl: New(),
+		lastCommit: make(map[string]time.Time),
+	}
+}
+
+func (p *ThroughputParser) Parse(block *common.Block) (*Data, error) {
+	data, err := p.ParserImpl.Parse(block)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &BlockStats{
+		Channel:         data.Channel,
+		BlockNumber:     data.BlockNumber,
+		Txs:             len(data.Txs),
+		ValidationCodes: make(map[string]int),
+		Bytes:           proto.Size(block),
+	}
+	for _, tx := range data.Txs {
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		} else if timestamp.After(stats.CommitTime) {
+			stats.CommitTime = timestamp
+		}
+		if tx.IsValid() {
+			stats.ValidTxs++
+		}
+		stats.ValidationCodes[peer.TxValidationCode(tx.ValidationCode()).String()]++
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if last, ok := p.lastCommit[data.Channel]; ok && stats.CommitTime.After(last) {
+		stats.SinceLastBlock = stats.CommitTime.Sub(last)
+	}
+	if stats.CommitTime.After(p.lastCommit[data.Channel]) {
+		p.lastCommit[data.Channel] = stats.CommitTime
+	}
+	data.BlockStats = stats
+	return data, nil
+}
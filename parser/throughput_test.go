@@ -0,0 +1,63 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestThroughputParser(t *testing.T) {
+	throughputParser := NewThroughputParser()
+
+	var stats []*BlockStats
+	for _, path := range []string{"../blocklib/mock/sampleblock.pb", "../blocklib/mock/mvcc_read_conflict.pb"} {
+		block, err := getBlock(path)
+		assert.NoError(t, err)
+		data, err := throughputParser.Parse(block)
+		assert.NoError(t, err)
+		assert.NotNil(t, data.BlockStats)
+		assert.False(t, data.BlockStats.CommitTime.IsZero())
+		assert.True(t, data.BlockStats.Bytes > 0)
+		stats = append(stats, data.BlockStats)
+	}
+
+	assert.Equal(t, 1, stats[0].Txs)
+	assert.Equal(t, map[string]int{"VALID": 1}, stats[0].ValidationCodes)
+	assert.Equal(t, time.Duration(0), stats[0].SinceLastBlock)
+
+	assert.Equal(t, 5, stats[1].Txs)
+	assert.Equal(t, stats[1].Txs, stats[1].ValidationCodes["VALID"]+stats[1].ValidationCodes["MVCC_READ_CONFLICT"])
+	assert.True(t, stats[1].ValidationCodes["MVCC_READ_CONFLICT"] > 0)
+	assert.Equal(t, stats[1].ValidationCodes["VALID"], stats[1].ValidTxs)
+	assert.Equal(t, stats[1].CommitTime.Sub(stats[0].CommitTime), stats[1].SinceLastBlock)
+}
+
+func TestRollup(t *testing.T) {
+	moment := time.Date(2020, 11, 5, 10, 42, 17, 0, time.UTC)
+	rollup, err := NewRollup("mychannel", GranularityHour, moment)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 11, 5, 10, 0, 0, 0, time.UTC), rollup.Start)
+
+	rollup.Add(&BlockStats{BlockNumber: 5, Txs: 10, ValidTxs: 9, ValidationCodes: map[string]int{"VALID": 9, "MVCC_READ_CONFLICT": 1}, Bytes: 1000})
+	rollup.Add(&BlockStats{BlockNumber: 6, Txs: 8, ValidTxs: 8, ValidationCodes: map[string]int{"VALID": 8}, Bytes: 3000, SinceLastBlock: 2 * time.Second})
+	assert.Equal(t, uint64(5), rollup.FirstBlock)
+	assert.Equal(t, uint64(6), rollup.LastBlock)
+	assert.Equal(t, map[string]int{"VALID": 17, "MVCC_READ_CONFLICT": 1}, rollup.ValidationCodes)
+	assert.InDelta(t, 2.0/60, rollup.BlocksPerMinute(), 1e-9)
+	assert.InDelta(t, 18.0/3600, rollup.TPS(), 1e-9)
+	assert.InDelta(t, 17.0/18, rollup.ValidRatio(), 1e-9)
+	assert.Equal(t, 2000.0, rollup.AvgBlockSize())
+	assert.Equal(t, 2*time.Second, rollup.AvgBlockInterval())
+
+	day, err := NewRollup("mychannel", GranularityDay, moment)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 11, 5, 0, 0, 0, 0, time.UTC), day.Start)
+
+	_, err = NewRollup("mychannel", "week", moment)
+	assert.EqualError(t, err, "unknown granularity week")
+}
@@ -12,6 +12,9 @@ import (
 	"strings"
 )
 
+// streamPageSize is the number of key-value pairs read from BadgerDB at once while streaming.
+const streamPageSize = 100
+
 type Badger struct {
 	db *badger.DB
 }
@@ -29,6 +32,8 @@ func NewBadger(path string) (*Badger, error) {
 	return &Badger{db}, nil
 }
 
+// InitChannelsStorage does nothing: channels share one database and storage adapters namespace keys by channel
+// (e.g. storageadapter.SimpleAdapter saves blocks by keys "block/<channel>/<number>").
 func (b *Badger) InitChannelsStorage(channels []string) error {
 	return nil
 }
@@ -40,11 +45,33 @@ func (b *Badger) Put(key string, value []byte) error {
 	})
 }
 
+// WriteBatch applies all the writes of the batch in a single BadgerDB transaction.
+// The batch must fit into one transaction (see badger.ErrTxnTooBig).
+func (b *Badger) WriteBatch(batch *Batch) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		for _, op := range batch.ops {
+			var err error
+			if op.Delete {
+				err = txn.Delete([]byte(op.Key))
+			} else {
+				err = txn.Set([]byte(op.Key), op.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Get retrieves data from BadgerDB using key
 func (b *Badger) Get(key string) ([]byte, error) {
 	var value []byte
 	err := b.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return ErrNotFound
+		}
 		if err != nil {
 			return err
 		}
@@ -68,6 +95,94 @@ func (b *Badger) Close() error {
 	return b.db.Close()
 }
 
-func (b *Badger) GetStream(key string) (<-chan []byte, <-chan error) {
-	return nil, nil
+// Iterate returns key-value pairs matching options in key order using BadgerDB iterator.
+func (b *Badger) Iterate(opts IterateOptions) (*IterateResult, error) {
+	result := &IterateResult{}
+	err := b.db.View(func(txn *badger.Txn) error {
+		iteratorOptions := badger.DefaultIteratorOptions
+		iteratorOptions.Reverse = opts.Reverse
+		iteratorOptions.Prefix = []byte(opts.Prefix)
+		it := txn.NewIterator(iteratorOptions)
+		defer it.Close()
+
+		for it.Seek(seekKey(opts)); it.ValidForPrefix(iteratorOptions.Prefix); it.Next() {
+			key := string(it.Item().Key())
+			if opts.Reverse {
+				if opts.End != "" && key >= opts.End || opts.Cursor != "" && key >= opts.Cursor {
+					continue
+				}
+				if key < opts.Start {
+					break
+				}
+			} else {
+				if key < opts.Start || opts.Cursor != "" && key <= opts.Cursor {
+					continue
+				}
+				if opts.End != "" && key >= opts.End {
+					break
+				}
+			}
+
+			if opts.Limit > 0 && len(result.KVs) == opts.Limit {
+				result.Cursor = result.KVs[len(result.KVs)-1].Key
+				break
+			}
+			value, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			result.KVs = append(result.KVs, KV{Key: key, Value: value})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// seekKey returns the key to start iteration from: the least key for forward iteration
+// and the greatest one for reverse iteration (reverse iterator seeks to the greatest key less than or equal to the key).
+func seekKey(opts IterateOptions) []byte {
+	seek := opts.Prefix
+	if !opts.Reverse {
+		if opts.Start > seek {
+			seek = opts.Start
+		}
+		if opts.Cursor > seek {
+			seek = opts.Cursor
+		}
+		return []byte(seek)
+	}
+
+	// keys are strings, so the greatest key with the prefix is less than the prefix followed by 0xff
+	seek += "\xff"
+	if opts.End != "" && opts.End < seek {
+		seek = opts.End
+	}
+	if opts.Cursor != "" && opts.Cursor < seek {
+		seek = opts.Cursor
+	}
+	return []byte(seek)
+}
+
+// GetStream streams values of the keys with the prefix in key order.
+func (b *Badger) GetStream(prefix string) (<-chan []byte, <-chan error) {
+	ch, errch := make(chan []byte), make(chan error, 1)
+	go func() {
+		defer close(ch)
+		opts := IterateOptions{Prefix: prefix, Limit: streamPageSize}
+		for {
+			page, err := b.Iterate(opts)
+			if err != nil {
+				errch <- err
+				return
+			}
+			for _, kv := range page.KVs {
+				ch <- kv.Value
+			}
+			if page.Cursor == "" {
+				return
+			}
+			opts.Cursor = page.Cursor
+		}
+	}()
+	return ch, errch
 }
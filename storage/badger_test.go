@@ -0,0 +1,158 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newBadger(t *testing.T) *Badger {
+	dir, err := ioutil.TempDir("", "crawler")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	stor, err := NewBadger(dir)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		stor.Close()
+	})
+	return stor
+}
+
+func keys(result *IterateResult) []string {
+	var keys []string
+	for _, kv := range result.KVs {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+func TestBadgerGet(t *testing.T) {
+	stor := newBadger(t)
+	assert.NoError(t, stor.Put("a", []byte("1")))
+	value, err := stor.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	_, err = stor.Get("b")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestBadgerIterate(t *testing.T) {
+	stor := newBadger(t)
+	for _, key := range []string{"a/1", "a/2", "a/3", "a/4", "b/1", "b/2", "c"} {
+		assert.NoError(t, stor.Put(key, []byte(key)))
+	}
+
+	result, err := stor.Iterate(IterateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a/1", "a/2", "a/3", "a/4", "b/1", "b/2", "c"}, keys(result))
+	assert.Equal(t, []byte("b/1"), result.KVs[4].Value)
+	assert.Empty(t, result.Cursor)
+
+	result, err = stor.Iterate(IterateOptions{Prefix: "b/"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b/1", "b/2"}, keys(result))
+
+	result, err = stor.Iterate(IterateOptions{Prefix: "b/", Reverse: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b/2", "b/1"}, keys(result))
+
+	result, err = stor.Iterate(IterateOptions{Start: "a/2", End: "b/2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a/2", "a/3", "a/4", "b/1"}, keys(result))
+
+	result, err = stor.Iterate(IterateOptions{Start: "a/2", End: "b/2", Reverse: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b/1", "a/4", "a/3", "a/2"}, keys(result))
+
+	result, err = stor.Iterate(IterateOptions{Prefix: "a/", End: "z", Reverse: true, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a/4"}, keys(result))
+}
+
+func TestBadgerIteratePages(t *testing.T) {
+	stor := newBadger(t)
+	for _, key := range []string{"a/1", "a/2", "a/3", "a/4", "a/5", "b/1"} {
+		assert.NoError(t, stor.Put(key, []byte(key)))
+	}
+
+	for _, reverse := range []bool{false, true} {
+		var pages [][]string
+		opts := IterateOptions{Prefix: "a/", Limit: 2, Reverse: reverse}
+		for {
+			result, err := stor.Iterate(opts)
+			assert.NoError(t, err)
+			pages = append(pages, keys(result))
+			if result.Cursor == "" {
+				break
+			}
+			opts.Cursor = result.Cursor
+		}
+		if reverse {
+			assert.Equal(t, [][]string{{"a/5", "a/4"}, {"a/3", "a/2"}, {"a/1"}}, pages)
+		} else {
+			assert.Equal(t, [][]string{{"a/1", "a/2"}, {"a/3", "a/4"}, {"a/5"}}, pages)
+		}
+	}
+
+	// the last page is full, but there are no more keys
+	result, err := stor.Iterate(IterateOptions{Prefix: "b/", Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b/1"}, keys(result))
+	assert.Empty(t, result.Cursor)
+}
+
+func TestBadgerGetStream(t *testing.T) {
+	stor := newBadger(t)
+	for i := 0; i < 250; i++ {
+		assert.NoError(t, stor.Put(string([]byte{'k', byte(i/26 + 'a'), byte(i%26 + 'a')}), []byte{byte(i)}))
+	}
+	assert.NoError(t, stor.Put("z", []byte("z")))
+
+	stream, errChan := stor.GetStream("k")
+	var values [][]byte
+	for value := range stream {
+		values = append(values, value)
+	}
+	assert.Len(t, errChan, 0)
+	assert.Len(t, values, 250)
+	assert.Equal(t, []byte{249}, values[249])
+}
+
+func TestBadgerWriteBatch(t *testing.T) {
+	stor := newBadger(t)
+	assert.NoError(t, stor.Put("a", []byte("old")))
+	assert.NoError(t, stor.Put("b", []byte("old")))
+
+	batch := NewBatch()
+	batch.Put("a", []byte("new"))
+	batch.Delete("b")
+	batch.Put("c", []byte("new"))
+	assert.Equal(t, 3, batch.Len())
+	assert.NoError(t, stor.WriteBatch(batch))
+
+	value, err := stor.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new"), value)
+	_, err = stor.Get("b")
+	assert.Equal(t, ErrNotFound, err)
+	value, err = stor.Get("c")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new"), value)
+
+	// nothing is written if any write of the batch fails
+	batch = NewBatch()
+	batch.Put("d", []byte("new"))
+	batch.Put("", []byte("empty key"))
+	assert.Error(t, stor.WriteBatch(batch))
+	_, err = stor.Get("d")
+	assert.Equal(t, ErrNotFound, err)
+}
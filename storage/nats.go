@@ -95,3 +95,13 @@ func (n *Nats) Close() error {
 	}
 	return n.Connection.Close()
 }
+
+// Iterate does not work for Nats.
+func (n *Nats) Iterate(opts IterateOptions) (*IterateResult, error) {
+	return nil, ErrNotSupported
+}
+
+// WriteBatch publishes messages of the batch one by one, NATS Streaming has no transactions.
+func (n *Nats) WriteBatch(batch *Batch) error {
+	return applyOneByOne(n, batch)
+}
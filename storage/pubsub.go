@@ -86,16 +86,20 @@ func (p *PubSub) Put(topic string, msg []byte) error {
 
 // Get reads one message from the topic and closes channel.
 func (p *PubSub) Get(topic string) ([]byte, error) {
-	var err error
-	ctx, _ := context.WithCancel(context.Background())
-	ch, errch := make(chan []byte), make(chan error)
+	// receiving is stopped as soon as the first message is read
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, errch := make(chan []byte), make(chan error, 1)
 	go func(ch chan []byte, errch chan error) {
-		err = p.subscriptions[topic].Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
-			ch <- m.Data
-			m.Ack()
-			return
+		err := p.subscriptions[topic].Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+			select {
+			case ch <- m.Data:
+				m.Ack()
+			case <-ctx.Done():
+				m.Nack()
+			}
 		})
-		if err != nil && err == context.Canceled {
+		if err != nil {
 			errch <- err
 		}
 	}(ch, errch)
@@ -103,7 +107,7 @@ func (p *PubSub) Get(topic string) ([]byte, error) {
 	select {
 	case data := <-ch:
 		return data, nil
-	case err = <-errch:
+	case err := <-errch:
 		return nil, err
 	}
 }
@@ -145,3 +149,13 @@ func (p *PubSub) Close() error {
 	}
 	return nil
 }
+
+// Iterate does not work for Google Pub/Sub.
+func (p *PubSub) Iterate(opts IterateOptions) (*IterateResult, error) {
+	return nil, ErrNotSupported
+}
+
+// WriteBatch publishes messages of the batch one by one, Google Pub/Sub has no transactions.
+func (p *PubSub) WriteBatch(batch *Batch) error {
+	return applyOneByOne(p, batch)
+}
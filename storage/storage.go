@@ -5,6 +5,14 @@ SPDX-License-Identifier: Apache-2.0
 
 package storage
 
+import "errors"
+
+// ErrNotFound is returned by Get of key-value storages if there is no value for the key.
+var ErrNotFound = errors.New("key not found")
+
+// ErrNotSupported is returned by storages (e.g. message brokers) which can't perform the operation.
+var ErrNotSupported = errors.New("operation is not supported by storage")
+
 // Storage interface is a contract for storage implementations
 type Storage interface {
 	// init storage (initial setup of storage and connection create operations)
@@ -15,8 +23,96 @@ type Storage interface {
 	Get(key string) ([]byte, error)
 	// get channel with some data from storage (for message broker storage implementations)
 	GetStream(key string) (<-chan []byte, <-chan error)
+	// iterate over key-value pairs in key order (for key-value storage implementations)
+	Iterate(opts IterateOptions) (*IterateResult, error)
+	// apply all the writes of the batch atomically (best-effort for storages without transactions)
+	WriteBatch(batch *Batch) error
 	// remove parser.Data from storage
 	Delete(key string) error
 	// close connection to storage (network connections, file descriptors, goroutines)
 	Close() error
 }
+
+// IterateOptions select key-value pairs to iterate over. All the conditions are combined.
+type IterateOptions struct {
+	Prefix  string // only keys with the prefix
+	Start   string // only keys greater than or equal to Start
+	End     string // only keys less than End (if not empty)
+	Reverse bool   // iterate in descending key order
+	Limit   int    // maximum number of pairs to return, 0 means no limit
+	Cursor  string // continue after the key (IterateResult.Cursor of the previous page)
+}
+
+// KV is a key-value pair stored in storage.
+type KV struct {
+	Key   string
+	Value []byte
+}
+
+// IterateResult is a page of key-value pairs.
+type IterateResult struct {
+	KVs    []KV
+	Cursor string // key of the last pair if there may be more pairs, empty if the iteration is over
+}
+
+// BatchOp is a single write of the batch: put of the value by key or deletion of the key.
+type BatchOp struct {
+	Key    string
+	Value  []byte
+	Delete bool
+}
+
+// Batch collects writes to be applied to storage at once with Storage.WriteBatch.
+type Batch struct {
+	ops []BatchOp
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Put adds put of the value by key to the batch.
+func (b *Batch) Put(key string, value []byte) {
+	b.ops = append(b.ops, BatchOp{Key: key, Value: value})
+}
+
+// Delete adds deletion of the key to the batch.
+func (b *Batch) Delete(key string) {
+	b.ops = append(b.ops, BatchOp{Key: key, Delete: true})
+}
+
+// Ops returns writes of the batch in the order they were added.
+func (b *Batch) Ops() []BatchOp {
+	return append([]BatchOp{}, b.ops...)
+}
+
+// Len returns the number of writes in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Size returns the number of bytes of the keys and values written by the batch.
+func (b *Batch) Size() int {
+	size := 0
+	for _, op := range b.ops {
+		size += len(op.Key) + len(op.Value)
+	}
+	return size
+}
+
+// applyOneByOne applies writes of the batch to storage one by one (for storages without transactions).
+// Writes which precede the failed one remain applied.
+func applyOneByOne(stor Storage, batch *Batch) error {
+	for _, op := range batch.ops {
+		var err error
+		if op.Delete {
+			err = stor.Delete(op.Key)
+		} else {
+			err = stor.Put(op.Key, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/newity/crawler/blocklib"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storageadapter/pb"
+	"reflect"
+	"sync"
+)
+
+// Codec marshals values saved to storage by adapters.
+type Codec interface {
+	// Name identifies the codec
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into the value pointed to by v
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// ErrUnsupportedValue is returned by codecs which can't marshal values of the type.
+var ErrUnsupportedValue = errors.New("value is not supported by codec")
+
+// IDs of the builtin codecs in the tags of the stored values
+const (
+	GobCodecID   byte = 1
+	JSONCodecID  byte = 2
+	ProtoCodecID byte = 3
+)
+
+// SchemaVersion is the version of the layout of the stored values written by the adapters without migrations (see Migrations).
+// Untagged values (saved before the values were tagged) have the layout of this version.
+const SchemaVersion byte = 1
+
+// tagMagic starts the tag of the stored value. Gob streams never start with 0xc0, so untagged (legacy gob) values are told apart.
+const tagMagic byte = 0xc0
+
+// tagLength is the length of the tag: magic byte, codec ID and schema version.
+const tagLength = 3
+
+var (
+	codecsMu   sync.RWMutex
+	codecsByID = map[byte]Codec{
+		GobCodecID:   GobCodec{},
+		JSONCodecID:  JSONCodec{},
+		ProtoCodecID: ProtoCodec{},
+	}
+)
+
+// RegisterCodec registers custom codec by ID, so values encoded by it can be decoded by any adapter.
+func RegisterCodec(id byte, codec Codec) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if registered, ok := codecsByID[id]; ok {
+		return fmt.Errorf("codec id %d is taken by codec %s", id, registered.Name())
+	}
+	codecsByID[id] = codec
+	return nil
+}
+
+func codecByID(id byte) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecsByID[id]
+	return codec, ok
+}
+
+func codecID(codec Codec) (byte, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for id, registered := range codecsByID {
+		if registered.Name() == codec.Name() {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("codec %s is not registered", codec.Name())
+}
+
+// Option configures storage adapter.
+type Option func(*options)
+
+type options struct {
+	codec      Codec
+	migrations *Migrations
+}
+
+// WithCodec sets the codec the adapter encodes values with (GobCodec by default).
+// Values are tagged with the codec, so values encoded with any registered codec are decoded regardless of the option.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithMigrations sets upgrade functions of the stored values. Values of the older schema versions are upgraded on read
+// and new values are written with the schema version of the migrations (see Migrations.Version).
+func WithMigrations(migrations *Migrations) Option {
+	return func(o *options) {
+		o.migrations = migrations
+	}
+}
+
+func applyOptions(opts []Option) *options {
+	o := &options{codec: GobCodec{}, migrations: NewMigrations()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// encode encodes the value with the codec and the schema version of the options.
+func (o *options) encode(v interface{}) ([]byte, error) {
+	return encodeWith(o.codec, o.migrations.Version(), v)
+}
+
+// decode decodes the value encoded with any registered codec and upgrades it to the schema version of the options.
+func (o *options) decode(data []byte, v interface{}) error {
+	version, err := decodeTagged(data, v)
+	if err != nil {
+		return err
+	}
+	return o.migrations.Upgrade(version, v)
+}
+
+// encodeWith marshals the value with the codec and tags it with the codec ID and the schema version.
+// Values which the codec doesn't support (ErrUnsupportedValue) are marshaled with JSONCodec.
+func encodeWith(codec Codec, version byte, v interface{}) ([]byte, error) {
+	encoded, err := codec.Marshal(v)
+	if errors.Is(err, ErrUnsupportedValue) {
+		codec = JSONCodec{}
+		encoded, err = codec.Marshal(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	id, err := codecID(codec)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tagMagic, id, version}, encoded...), nil
+}
+
+// decodeTagged decodes the value with the codec it was tagged with (untagged values are gob-encoded)
+// and returns the schema version of the value.
+func decodeTagged(data []byte, v interface{}) (byte, error) {
+	if !isTagged(data) {
+		return SchemaVersion, GobCodec{}.Unmarshal(data, v)
+	}
+	codec, ok := codecByID(data[1])
+	if !ok {
+		return 0, fmt.Errorf("unknown codec id %d", data[1])
+	}
+	return data[2], codec.Unmarshal(data[tagLength:], v)
+}
+
+func isTagged(data []byte) bool {
+	return len(data) >= tagLength && data[0] == tagMagic
+}
+
+// GobCodec encodes values with encoding/gob, as the adapters always did. It is the default codec.
+// Transactions (blocklib.Tx) are encoded with their validation codes and statuses. Values encoded by the previous versions
+// kept the envelopes of the transactions only, they are decoded with empty validation statuses.
+type GobCodec struct{}
+
+func (GobCodec) Name() string {
+	return "gob"
+}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var bytebuffer bytes.Buffer
+	e := gob.NewEncoder(&bytebuffer)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytebuffer.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	err := gob.NewDecoder(bytes.NewBuffer(data)).Decode(v)
+	if err == nil {
+		return nil
+	}
+	if legacyErr := unmarshalLegacyGob(data, v); legacyErr != nil {
+		return err
+	}
+	return nil
+}
+
+// legacyTx is the layout of blocklib.Tx encoded by gob before it implemented gob.GobEncoder.
+type legacyTx struct {
+	Data []byte
+}
+
+var (
+	txType           = reflect.TypeOf(blocklib.Tx{})
+	legacyTypesMu    sync.Mutex
+	legacyTypesCache = make(map[reflect.Type]reflect.Type)
+)
+
+// unmarshalLegacyGob decodes the struct with transaction fields ([]blocklib.Tx or *blocklib.Tx, e.g. parser.Data and parser.Record)
+// encoded before blocklib.Tx implemented gob.GobEncoder.
+func unmarshalLegacyGob(data []byte, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return ErrUnsupportedValue
+	}
+	legacyType, ok := legacyGobType(value.Elem().Type())
+	if !ok {
+		return ErrUnsupportedValue
+	}
+	legacy := reflect.New(legacyType)
+	if err := gob.NewDecoder(bytes.NewBuffer(data)).Decode(legacy.Interface()); err != nil {
+		return err
+	}
+	target := value.Elem()
+	for i := 0; i < target.NumField(); i++ {
+		field := legacy.Elem().Field(i)
+		switch target.Field(i).Type() {
+		case reflect.SliceOf(txType):
+			txs := make([]blocklib.Tx, 0, field.Len())
+			for _, tx := range field.Interface().([]legacyTx) {
+				txs = append(txs, blocklib.NewTx(tx.Data, 0, ""))
+			}
+			target.Field(i).Set(reflect.ValueOf(txs))
+		case reflect.PtrTo(txType):
+			if tx := field.Interface().(*legacyTx); tx != nil {
+				decoded := blocklib.NewTx(tx.Data, 0, "")
+				target.Field(i).Set(reflect.ValueOf(&decoded))
+			}
+		default:
+			target.Field(i).Set(field)
+		}
+	}
+	return nil
+}
+
+// legacyGobType returns the struct type with transaction fields replaced with legacyTx, false if there are no transaction fields.
+func legacyGobType(t reflect.Type) (reflect.Type, bool) {
+	legacyTypesMu.Lock()
+	defer legacyTypesMu.Unlock()
+	if legacyType, ok := legacyTypesCache[t]; ok {
+		return legacyType, legacyType != nil
+	}
+	fields := make([]reflect.StructField, t.NumField())
+	hasTxs := false
+	for i := range fields {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported fields are not encoded by gob, and reflect.StructOf doesn't support them
+			legacyTypesCache[t] = nil
+			return nil, false
+		}
+		switch field.Type {
+		case reflect.SliceOf(txType):
+			field.Type, hasTxs = reflect.TypeOf([]legacyTx{}), true
+		case reflect.PtrTo(txType):
+			field.Type, hasTxs = reflect.TypeOf(&legacyTx{}), true
+		}
+		fields[i] = field
+	}
+	var legacyType reflect.Type
+	if hasTxs {
+		legacyType = reflect.StructOf(fields)
+	}
+	legacyTypesCache[t] = legacyType
+	return legacyType, hasTxs
+}
+
+// JSONCodec encodes values with encoding/json, so stored data can be read outside Go.
+// Values of interface fields (e.g. parser.Arg.Value) are decoded as generic JSON values.
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtoCodec encodes protobuf messages and parser.Data (as pb.Data, see pb/data.proto) with protobuf.
+// Fields of parser.Data filled by the extended parsers are kept in pb.Data.Extensions in JSON.
+// Other values are not supported, adapters encode them with JSONCodec.
+type ProtoCodec struct{}
+
+func (ProtoCodec) Name() string {
+	return "proto"
+}
+
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case *parser.Data:
+		message, err := dataToProto(v)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(message)
+	case proto.Message:
+		return proto.Marshal(v)
+	}
+	return nil, ErrUnsupportedValue
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *parser.Data:
+		message := &pb.Data{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		return dataFromProto(message, v)
+	case proto.Message:
+		return proto.Unmarshal(data, v)
+	}
+	return ErrUnsupportedValue
+}
+
+func dataToProto(data *parser.Data) (*pb.Data, error) {
+	message := &pb.Data{
+		BlockNumber: data.BlockNumber,
+		Prevhash:    data.Prevhash,
+		Datahash:    data.Datahash,
+		Channel:     data.Channel,
+		Events:      data.Events,
+	}
+	for _, signature := range data.BlockSignatures {
+		message.BlockSignatures = append(message.BlockSignatures, &pb.BlockSignature{
+			Cert:        signature.Cert,
+			MspId:       signature.MSPID,
+			Signature:   signature.Signature,
+			Nonce:       signature.Nonce,
+			ConsenterId: signature.ConsenterId,
+		})
+	}
+	for _, tx := range data.Txs {
+		message.Txs = append(message.Txs, &pb.Tx{
+			Data:             tx.Data,
+			ValidationCode:   tx.ValidationCode(),
+			ValidationStatus: tx.ValidationStatus(),
+		})
+	}
+
+	extensions := *data
+	extensions.BlockNumber, extensions.Prevhash, extensions.Datahash, extensions.Channel = 0, nil, nil, ""
+	extensions.BlockSignatures, extensions.Txs, extensions.Events = nil, nil, nil
+	if !reflect.DeepEqual(extensions, parser.Data{}) {
+		encoded, err := json.Marshal(&extensions)
+		if err != nil {
+			return nil, err
+		}
+		message.Extensions = encoded
+	}
+	return message, nil
+}
+
+func dataFromProto(message *pb.Data, data *parser.Data) error {
+	*data = parser.Data{}
+	if len(message.Extensions) > 0 {
+		if err := json.Unmarshal(message.Extensions, data); err != nil {
+			return err
+		}
+	}
+	data.BlockNumber = message.BlockNumber
+	data.Prevhash = message.Prevhash
+	data.Datahash = message.Datahash
+	data.Channel = message.Channel
+	data.Events = message.Events
+	for _, signature := range message.BlockSignatures {
+		data.BlockSignatures = append(data.BlockSignatures, blocklib.BlockSignature{
+			Cert:        signature.Cert,
+			MSPID:       signature.MspId,
+			Signature:   signature.Signature,
+			Nonce:       signature.Nonce,
+			ConsenterId: signature.ConsenterId,
+		})
+	}
+	for _, tx := range message.Txs {
+		data.Txs = append(data.Txs, blocklib.NewTx(tx.Data, tx.ValidationCode, tx.ValidationStatus))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/newity/crawler/blocklib"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storageadapter/pb"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func dataWithExtensions(t *testing.T) *parser.Data {
+	data := parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb")
+	data.BlockStats = &parser.BlockStats{Channel: data.Channel, BlockNumber: data.BlockNumber, Txs: 5, SinceLastBlock: time.Second}
+	data.ChaincodeDefinitions = []parser.ChaincodeDefinitionRecord{{
+		ChaincodeDefinition: blocklib.ChaincodeDefinition{
+			Operation: blocklib.LifecycleCommit,
+			Name:      "fabcar",
+			Sequence:  1,
+			EndorsementPolicy: &common.SignaturePolicyEnvelope{
+				Rule: &common.SignaturePolicy{Type: &common.SignaturePolicy_SignedBy{SignedBy: 0}},
+			},
+		},
+		Channel:     data.Channel,
+		BlockNumber: data.BlockNumber,
+	}}
+	return data
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{GobCodec{}, JSONCodec{}, ProtoCodec{}} {
+		adapter := NewSimpleAdapter(newBadger(t), WithCodec(codec))
+		data := dataWithExtensions(t)
+		assert.NoError(t, adapter.Inject(data))
+
+		decoded, err := adapter.RetrieveBlock("mychannel", 35)
+		assert.NoError(t, err, codec.Name())
+		assert.Equal(t, data.Prevhash, decoded.Prevhash, codec.Name())
+		assert.Equal(t, data.BlockSignatures, decoded.BlockSignatures, codec.Name())
+		assert.Len(t, decoded.Txs, 5, codec.Name())
+		assert.Equal(t, data.Txs[0].Data, decoded.Txs[0].Data, codec.Name())
+		assert.Equal(t, data.BlockStats, decoded.BlockStats, codec.Name())
+		assert.Len(t, decoded.ChaincodeDefinitions, 1, codec.Name())
+		assert.Equal(t, "fabcar", decoded.ChaincodeDefinitions[0].Name, codec.Name())
+		assert.Equal(t, uint64(35), decoded.ChaincodeDefinitions[0].BlockNumber, codec.Name())
+		assert.True(t, proto.Equal(data.ChaincodeDefinitions[0].EndorsementPolicy, decoded.ChaincodeDefinitions[0].EndorsementPolicy), codec.Name())
+		assert.False(t, decoded.Txs[0].IsValid(), codec.Name())
+		assert.Equal(t, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), decoded.Txs[0].ValidationCode(), codec.Name())
+		assert.Equal(t, "MVCC_READ_CONFLICT", decoded.Txs[0].ValidationStatus(), codec.Name())
+	}
+}
+
+func TestGobCodecTx(t *testing.T) {
+	valid := blocklib.NewTx([]byte("envelope"), 0, "VALID")
+	invalid := blocklib.NewTx([]byte("envelope"), int32(peer.TxValidationCode_MVCC_READ_CONFLICT), "MVCC_READ_CONFLICT")
+	for _, tx := range []blocklib.Tx{valid, invalid, {}} {
+		encoded, err := GobCodec{}.Marshal(&parser.Record{TxId: "tx", Tx: &tx})
+		assert.NoError(t, err)
+		decoded := &parser.Record{}
+		assert.NoError(t, GobCodec{}.Unmarshal(encoded, decoded))
+		assert.Equal(t, tx.IsValid(), decoded.Tx.IsValid())
+		assert.Equal(t, tx.ValidationCode(), decoded.Tx.ValidationCode())
+		assert.Equal(t, tx.ValidationStatus(), decoded.Tx.ValidationStatus())
+		assert.Equal(t, len(tx.Data), len(decoded.Tx.Data))
+	}
+
+	// values encoded before blocklib.Tx was gob-encoded with its validation code keep the envelopes
+	legacy, err := GobCodec{}.Marshal(&struct {
+		BlockNumber uint64
+		Channel     string
+		Txs         []legacyTx
+		Events      []*peer.ChaincodeEvent
+	}{35, "mychannel", []legacyTx{{Data: []byte("envelope")}}, []*peer.ChaincodeEvent{{EventName: "Transfer"}}})
+	assert.NoError(t, err)
+	data := &parser.Data{}
+	assert.NoError(t, GobCodec{}.Unmarshal(legacy, data))
+	assert.Equal(t, uint64(35), data.BlockNumber)
+	assert.Equal(t, "mychannel", data.Channel)
+	assert.Equal(t, []byte("envelope"), data.Txs[0].Data)
+	assert.Equal(t, "Transfer", data.Events[0].EventName)
+
+	legacy, err = GobCodec{}.Marshal(&struct {
+		TxId string
+		Tx   *legacyTx
+	}{"tx", &legacyTx{Data: []byte("envelope")}})
+	assert.NoError(t, err)
+	record := &parser.Record{}
+	assert.NoError(t, GobCodec{}.Unmarshal(legacy, record))
+	assert.Equal(t, "tx", record.TxId)
+	assert.Equal(t, []byte("envelope"), record.Tx.Data)
+
+	assert.Error(t, GobCodec{}.Unmarshal([]byte("garbage"), &parser.Data{}))
+}
+
+func TestCodecsTags(t *testing.T) {
+	stor := newBadger(t)
+	data := &parser.Data{Channel: "mychannel", BlockNumber: 1, Events: []*peer.ChaincodeEvent{{ChaincodeId: "cc", EventName: "Transfer"}}}
+
+	// untagged value saved by the previous versions
+	legacy, err := GobCodec{}.Marshal(data)
+	assert.NoError(t, err)
+	assert.NoError(t, stor.Put(blockKey("mychannel", 1), legacy))
+	assert.NoError(t, NewSimpleAdapter(stor, WithCodec(JSONCodec{})).Inject(&parser.Data{Channel: "mychannel", BlockNumber: 2}))
+	data.BlockNumber = 3
+	assert.NoError(t, NewSimpleAdapter(stor, WithCodec(ProtoCodec{})).Inject(data))
+
+	// mixed storage is read by the adapter with any codec
+	assert.Equal(t, []uint64{1, 2, 3}, readAll(t, NewSimpleAdapter(stor), "mychannel/1-"))
+	assert.Equal(t, []uint64{1, 2, 3}, readAll(t, NewSimpleAdapter(stor, WithCodec(ProtoCodec{})), "mychannel/1-"))
+
+	value, err := stor.Get(blockKey("mychannel", 3))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{tagMagic, ProtoCodecID, SchemaVersion}, value[:tagLength])
+	// protobuf-encoded data can be read with pb/data.proto
+	message := &pb.Data{}
+	assert.NoError(t, proto.Unmarshal(value[tagLength:], message))
+	assert.Equal(t, "mychannel", message.Channel)
+	assert.Equal(t, "Transfer", message.Events[0].EventName)
+	assert.Empty(t, message.Extensions)
+
+	// records are not supported by ProtoCodec and encoded with JSONCodec
+	adapter := NewSimpleAdapter(stor, WithCodec(ProtoCodec{}))
+	record := &parser.Record{ID: parser.RecordID{Channel: "mychannel", BlockNumber: 2, Type: parser.RecordTx}, TxId: "tx"}
+	assert.NoError(t, adapter.InjectRecords([]*parser.Record{record}))
+	value, err = stor.Get(recordPrefix + record.ID.Key())
+	assert.NoError(t, err)
+	assert.Equal(t, JSONCodecID, value[1])
+	decoded, err := adapter.RetrieveRecord(record.ID.Key())
+	assert.NoError(t, err)
+	assert.Equal(t, "tx", decoded.TxId)
+
+	_, err = Decode([]byte{tagMagic, 42, SchemaVersion})
+	assert.Error(t, err)
+	assert.Error(t, RegisterCodec(JSONCodecID, JSONCodec{}))
+}
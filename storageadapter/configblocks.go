@@ -0,0 +1,51 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/storage"
+)
+
+const configBlockPrefix = "configblock/"
+
+// ConfigBlockStorage keeps config blocks in storage and implements blocklib.ConfigBlockResolver,
+// so BFT blocks can be parsed offline (without a live ledger client).
+type ConfigBlockStorage struct {
+	storage storage.Storage
+}
+
+func NewConfigBlockStorage(stor storage.Storage) *ConfigBlockStorage {
+	return &ConfigBlockStorage{stor}
+}
+
+// Put saves config block of the channel by key "configblock/<channel>/<number>".
+func (s *ConfigBlockStorage) Put(channel string, block *common.Block) error {
+	encoded, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return s.storage.Put(configBlockKey(channel, block.Header.Number), encoded)
+}
+
+// ConfigBlock retrieves config block with number 'number' of the channel.
+func (s *ConfigBlockStorage) ConfigBlock(channel string, number uint64) (*common.Block, error) {
+	value, err := s.storage.Get(configBlockKey(channel, number))
+	if err != nil {
+		return nil, err
+	}
+	block := &common.Block{}
+	if err = proto.Unmarshal(value, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func configBlockKey(channel string, number uint64) string {
+	return fmt.Sprintf("%s%s/%d", configBlockPrefix, channel, number)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"encoding/gob"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/newity/crawler/parser"
+)
+
+func init() {
+	// implementations of protobuf oneof fields which may be found in parser.Data
+	gob.Register(&common.SignaturePolicy_SignedBy{})
+	gob.Register(&common.SignaturePolicy_NOutOf_{})
+	gob.Register(&peer.CollectionConfig_StaticCollectionConfig{})
+	gob.Register(&peer.CollectionPolicyConfig_SignaturePolicy{})
+	// values of decoded chaincode arguments (parser.Arg)
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// Encode encodes parser.Data with GobCodec (adapters use the codec they are configured with).
+func Encode(data *parser.Data) ([]byte, error) {
+	return encodeWith(GobCodec{}, SchemaVersion, data)
+}
+
+// Decode decodes parser.Data encoded with any registered codec (without upgrading it, see Migrations).
+func Decode(data []byte) (*parser.Data, error) {
+	decoded := &parser.Data{}
+	if _, err := decodeTagged(data, decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
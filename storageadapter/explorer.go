@@ -0,0 +1,566 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/newity/crawler/blocklib"
+	"github.com/newity/crawler/parser"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+// explorerSchema creates the tables of Hyperledger Explorer (explorerpg.sql) filled by ExplorerAdapter.
+// $serial is replaced with the auto-incremented primary key type of the dialect.
+var explorerSchema = []string{
+	`CREATE TABLE IF NOT EXISTS blocks (
+	id $serial,
+	blocknum integer DEFAULT NULL,
+	datahash character varying(256) DEFAULT NULL,
+	prehash character varying(256) DEFAULT NULL,
+	txcount integer DEFAULT NULL,
+	createdt timestamp DEFAULT NULL,
+	prev_blockhash character varying(256) DEFAULT NULL,
+	blockhash character varying(256) DEFAULT NULL,
+	channel_genesis_hash character varying(256) DEFAULT NULL,
+	blksize integer DEFAULT NULL,
+	network_name varchar(255)
+)`,
+	`CREATE INDEX IF NOT EXISTS blocks_blocknum_idx ON blocks (blocknum)`,
+	`CREATE INDEX IF NOT EXISTS blocks_channel_genesis_hash_idx ON blocks (channel_genesis_hash)`,
+	`CREATE INDEX IF NOT EXISTS blocks_createdt_idx ON blocks (createdt)`,
+	`CREATE TABLE IF NOT EXISTS chaincodes (
+	id $serial,
+	name character varying(255) DEFAULT NULL,
+	version character varying(255) DEFAULT NULL,
+	path character varying(255) DEFAULT NULL,
+	channel_genesis_hash character varying(256) DEFAULT NULL,
+	txcount integer DEFAULT 0,
+	createdt timestamp DEFAULT NULL,
+	network_name varchar(255)
+)`,
+	`CREATE TABLE IF NOT EXISTS channel (
+	id $serial,
+	name character varying(256) DEFAULT NULL,
+	blocks integer DEFAULT NULL,
+	trans integer DEFAULT NULL,
+	createdt timestamp DEFAULT NULL,
+	channel_genesis_hash character varying(256) DEFAULT NULL,
+	channel_hash character varying(256) DEFAULT NULL,
+	channel_config bytea DEFAULT NULL,
+	channel_block bytea DEFAULT NULL,
+	channel_tx bytea DEFAULT NULL,
+	channel_version character varying(128) DEFAULT NULL,
+	network_name varchar(255)
+)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+	id $serial,
+	blockid integer DEFAULT NULL,
+	txhash character varying(256) DEFAULT NULL,
+	createdt timestamp DEFAULT NULL,
+	chaincodename character varying(255) DEFAULT NULL,
+	status integer DEFAULT NULL,
+	creator_msp_id character varying(256) DEFAULT NULL,
+	endorser_msp_id character varying(800) DEFAULT NULL,
+	chaincode_id character varying(256) DEFAULT NULL,
+	type character varying(256) DEFAULT NULL,
+	read_set json DEFAULT NULL,
+	write_set json DEFAULT NULL,
+	channel_genesis_hash character varying(256) DEFAULT NULL,
+	validation_code character varying(255) DEFAULT NULL,
+	envelope_signature character varying DEFAULT NULL,
+	payload_extension character varying DEFAULT NULL,
+	creator_id_bytes character varying DEFAULT NULL,
+	creator_nonce character varying DEFAULT NULL,
+	chaincode_proposal_input character varying DEFAULT NULL,
+	tx_response character varying DEFAULT NULL,
+	payload_proposal_hash character varying DEFAULT NULL,
+	endorser_id_bytes character varying DEFAULT NULL,
+	endorser_signature character varying DEFAULT NULL,
+	network_name varchar(255)
+)`,
+	`CREATE INDEX IF NOT EXISTS transactions_txhash_idx ON transactions (txhash)`,
+	`CREATE INDEX IF NOT EXISTS transactions_channel_genesis_hash_idx ON transactions (channel_genesis_hash)`,
+	`CREATE INDEX IF NOT EXISTS transactions_createdt_idx ON transactions (createdt)`,
+	`CREATE INDEX IF NOT EXISTS transactions_blockid_idx ON transactions (blockid)`,
+	`CREATE INDEX IF NOT EXISTS transactions_chaincodename_idx ON transactions (chaincodename)`,
+}
+
+var explorerTxColumns = []string{
+	"blockid", "txhash", "createdt", "chaincodename", "status", "creator_msp_id", "endorser_msp_id", "chaincode_id", "type",
+	"read_set", "write_set", "channel_genesis_hash", "validation_code", "envelope_signature", "creator_id_bytes", "creator_nonce",
+	"chaincode_proposal_input", "payload_proposal_hash", "network_name",
+}
+
+// errExplorerRead is returned by the read methods of ExplorerAdapter.
+var errExplorerRead = errors.New("explorer schema doesn't keep parsed blocks")
+
+// explorerReadSet and explorerWriteSet are the elements of transactions.read_set and write_set as Explorer saves them.
+type explorerReadSet struct {
+	Chaincode string            `json:"chaincode"`
+	Set       []explorerKeyRead `json:"set"`
+}
+
+type explorerKeyRead struct {
+	Key     string           `json:"key"`
+	Version *explorerVersion `json:"version"`
+}
+
+type explorerVersion struct {
+	BlockNum uint64 `json:"block_num"`
+	TxNum    uint64 `json:"tx_num"`
+}
+
+type explorerWriteSet struct {
+	Chaincode string             `json:"chaincode"`
+	Set       []explorerKeyWrite `json:"set"`
+}
+
+type explorerKeyWrite struct {
+	Key      string `json:"key"`
+	IsDelete bool   `json:"is_delete"`
+	Value    string `json:"value"`
+}
+
+// explorerChaincode is the key of the chaincodes rows.
+type explorerChaincode struct {
+	name, version string
+}
+
+// explorerAction is the first action of the transaction as Explorer saves it.
+type explorerAction struct {
+	chaincode explorerChaincode
+	status    *int32 // status of the chaincode response
+	endorsers string // MSP IDs of the endorsers as PostgreSQL array literal
+	input     string // comma-separated chaincode input
+	proposal  string // hex-encoded proposal hash
+}
+
+// ExplorerAdapter fills the PostgreSQL schema of Hyperledger Explorer (tables blocks, transactions, chaincodes and channel)
+// with crawled blocks, so the crawler can replace the sync process of Explorer: its dashboards read the tables as they are.
+// Rows are saved for the network name of Explorer configuration and the channel is identified by channel_genesis_hash
+// (hex-encoded header hash of block 0), which is taken from the channel row of Explorer, from block 0 when it is injected
+// or registered with RegisterChannel before crawling from the middle of the chain.
+// Inject is idempotent: the rows of the block and its transactions are replaced and the counters of the channel
+// and the chaincodes are corrected in one database transaction. blocks.blksize is the size of the block data (transactions),
+// chaincodes.path is not filled. transactions.status is the status of the chaincode response (NULL for config transactions).
+// Parsers leave the transactions of config blocks out of parser.Data.Txs, so their config transaction (parser.Data.ConfigTx)
+// is saved instead, as Explorer does: it is counted in txcount and dates the block and the channel.
+// Explorer doesn't keep the data of the blocks, so Retrieve and ReadStream are not supported.
+type ExplorerAdapter struct {
+	db      *sql.DB
+	dialect SQLDialect
+	network string
+	mu      sync.Mutex
+	genesis map[string]string // genesis hashes by channel name
+}
+
+// NewExplorerAdapter creates ExplorerAdapter over Explorer database for the network 'network' (network name of Explorer config).
+func NewExplorerAdapter(db *sql.DB, dialect SQLDialect, network string) *ExplorerAdapter {
+	return &ExplorerAdapter{db: db, dialect: dialect, network: network, genesis: make(map[string]string)}
+}
+
+// InitSchema creates the tables filled by the adapter which don't exist yet, e.g. for the database without Explorer.
+// The other tables of Explorer (peers, orderers, users) are created by Explorer database scripts.
+func (s *ExplorerAdapter) InitSchema() error {
+	for _, statement := range explorerSchema {
+		if _, err := s.db.Exec(strings.Replace(statement, "$serial", s.dialect.SerialType(), 1)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// RegisterChannel saves the channel row with the genesis hash of the channel (hex-encoded header hash of block 0),
+// if the channel is not saved yet.
+func (s *ExplorerAdapter) RegisterChannel(channel, genesisHash string, created time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = s.registerChannel(tx, channel, genesisHash, created); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logrus.Errorf("failed to rollback transaction: %s", rollbackErr)
+		}
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.genesis[channel] = genesisHash
+	return nil
+}
+
+func (s *ExplorerAdapter) registerChannel(tx *sql.Tx, channel, genesisHash string, created time.Time) error {
+	var id int64
+	err := tx.QueryRow(rebind(s.dialect, "SELECT id FROM channel WHERE name = ? AND network_name = ?"), channel, s.network).Scan(&id)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	_, err = tx.Exec(rebind(s.dialect, insertStatement("channel", []string{"name", "blocks", "trans", "createdt", "channel_genesis_hash", "network_name"})),
+		channel, 0, 0, created, genesisHash, s.network)
+	return err
+}
+
+// GenesisHash returns the genesis hash of the channel saved to the channel table.
+func (s *ExplorerAdapter) GenesisHash(channel string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hash, ok := s.genesis[channel]; ok {
+		return hash, nil
+	}
+	var hash string
+	statement := rebind(s.dialect, "SELECT channel_genesis_hash FROM channel WHERE name = ? AND network_name = ?")
+	err := s.db.QueryRow(statement, channel, s.network).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("channel %s is not registered: inject block 0 or call RegisterChannel", channel)
+	}
+	if err != nil {
+		return "", err
+	}
+	s.genesis[channel] = hash
+	return hash, nil
+}
+
+func (s *ExplorerAdapter) Inject(data *parser.Data) error {
+	blockHash := explorerBlockHash(data)
+	created := explorerCreated(data)
+	if data.BlockNumber == 0 {
+		if err := s.RegisterChannel(data.Channel, blockHash, created); err != nil {
+			return err
+		}
+	}
+	genesisHash, err := s.GenesisHash(data.Channel)
+	if err != nil {
+		return err
+	}
+
+	size := 0
+	for _, tx := range explorerTxs(data) {
+		size += len(tx.Data)
+	}
+	block := []interface{}{
+		int64(data.BlockNumber), hex.EncodeToString(data.Datahash), hex.EncodeToString(data.Prevhash), len(explorerTxs(data)), created,
+		hex.EncodeToString(data.Prevhash), blockHash, genesisHash, size, s.network,
+	}
+	txs, chaincodes := s.txRows(data, genesisHash)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = s.replaceBlock(tx, data, genesisHash, block, txs, chaincodes); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logrus.Errorf("failed to rollback transaction: %s", rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// replaceBlock replaces the rows of the block and its transactions and corrects the counters of the channel and the chaincodes.
+func (s *ExplorerAdapter) replaceBlock(tx *sql.Tx, data *parser.Data, genesisHash string, block []interface{}, txs [][]interface{}, chaincodes map[explorerChaincode]int) error {
+	number := int64(data.BlockNumber)
+	var oldTxs int
+	err := tx.QueryRow(rebind(s.dialect, "SELECT txcount FROM blocks WHERE blocknum = ? AND channel_genesis_hash = ? AND network_name = ?"),
+		number, genesisHash, s.network).Scan(&oldTxs)
+	newBlock := err == sql.ErrNoRows
+	if err != nil && !newBlock {
+		return err
+	}
+	deltas, err := s.chaincodeCounts(tx, number, genesisHash)
+	if err != nil {
+		return err
+	}
+	for chaincode, count := range deltas {
+		deltas[chaincode] = -count
+	}
+	for chaincode, count := range chaincodes {
+		deltas[chaincode] += count
+	}
+
+	for _, table := range []string{"transactions", "blocks"} {
+		column := "blockid"
+		if table == "blocks" {
+			column = "blocknum"
+		}
+		statement := rebind(s.dialect, "DELETE FROM "+table+" WHERE "+column+" = ? AND channel_genesis_hash = ? AND network_name = ?")
+		if _, err = tx.Exec(statement, number, genesisHash, s.network); err != nil {
+			return fmt.Errorf("failed to delete rows from %s: %w", table, err)
+		}
+	}
+	blockColumns := []string{
+		"blocknum", "datahash", "prehash", "txcount", "createdt", "prev_blockhash", "blockhash", "channel_genesis_hash", "blksize", "network_name",
+	}
+	if _, err = tx.Exec(rebind(s.dialect, insertStatement("blocks", blockColumns)), block...); err != nil {
+		return fmt.Errorf("failed to insert into blocks: %w", err)
+	}
+	if len(txs) > 0 {
+		stmt, err := tx.Prepare(rebind(s.dialect, insertStatement("transactions", explorerTxColumns)))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, row := range txs {
+			if _, err = stmt.Exec(row...); err != nil {
+				return fmt.Errorf("failed to insert into transactions: %w", err)
+			}
+		}
+	}
+
+	for chaincode, delta := range deltas {
+		if err = s.updateChaincode(tx, chaincode, genesisHash, delta, explorerCreated(data)); err != nil {
+			return err
+		}
+	}
+	blocks := 0
+	if newBlock {
+		blocks = 1
+	}
+	statement := rebind(s.dialect, "UPDATE channel SET blocks = blocks + ?, trans = trans + ? WHERE channel_genesis_hash = ? AND network_name = ?")
+	_, err = tx.Exec(statement, blocks, len(explorerTxs(data))-oldTxs, genesisHash, s.network)
+	return err
+}
+
+// chaincodeCounts returns the numbers of the saved transactions of the block by chaincode.
+func (s *ExplorerAdapter) chaincodeCounts(tx *sql.Tx, number int64, genesisHash string) (map[explorerChaincode]int, error) {
+	statement := rebind(s.dialect, "SELECT chaincodename, chaincode_id, COUNT(*) FROM transactions "+
+		"WHERE blockid = ? AND channel_genesis_hash = ? AND network_name = ? AND chaincodename <> '' GROUP BY chaincodename, chaincode_id")
+	rows, err := tx.Query(statement, number, genesisHash, s.network)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[explorerChaincode]int)
+	for rows.Next() {
+		var (
+			name, id string
+			count    int
+		)
+		if err = rows.Scan(&name, &id, &count); err != nil {
+			return nil, err
+		}
+		counts[explorerChaincode{name: name, version: strings.TrimPrefix(id, name+":")}] = count
+	}
+	return counts, rows.Err()
+}
+
+// updateChaincode adds delta to the transaction counter of the chaincode version, the chaincode row is created if it doesn't exist.
+func (s *ExplorerAdapter) updateChaincode(tx *sql.Tx, chaincode explorerChaincode, genesisHash string, delta int, created time.Time) error {
+	var id int64
+	err := tx.QueryRow(rebind(s.dialect, "SELECT id FROM chaincodes WHERE name = ? AND version = ? AND channel_genesis_hash = ? AND network_name = ?"),
+		chaincode.name, chaincode.version, genesisHash, s.network).Scan(&id)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(rebind(s.dialect, insertStatement("chaincodes", []string{"name", "version", "channel_genesis_hash", "txcount", "createdt", "network_name"})),
+			chaincode.name, chaincode.version, genesisHash, delta, created, s.network)
+		return err
+	}
+	if err != nil || delta == 0 {
+		return err
+	}
+	_, err = tx.Exec(rebind(s.dialect, "UPDATE chaincodes SET txcount = txcount + ? WHERE id = ?"), delta, id)
+	return err
+}
+
+// txRows returns the rows of the transactions of the block and the numbers of the transactions by chaincode version.
+func (s *ExplorerAdapter) txRows(data *parser.Data, genesisHash string) ([][]interface{}, map[explorerChaincode]int) {
+	var rows [][]interface{}
+	chaincodes := make(map[explorerChaincode]int)
+	txs := explorerTxs(data)
+	for i := range txs {
+		tx := &txs[i]
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction id: %s", err)
+			continue
+		}
+		header, err := tx.ChannelHeader()
+		if err != nil {
+			logrus.Errorf("failed to get channel header: %s", err)
+			continue
+		}
+		var createdAt *time.Time
+		if timestamp, err := tx.Timestamp(); err == nil {
+			createdAt = &timestamp
+		}
+		creatorMSP, creatorCert, err := tx.Creator()
+		if err != nil {
+			logrus.Errorf("failed to get transaction creator: %s", err)
+		}
+		var nonce, signature string
+		if signatureHeader, err := tx.SignatureHeader(); err == nil {
+			nonce = hex.EncodeToString(signatureHeader.Nonce)
+		}
+		if envelope, err := tx.Envelope(); err == nil {
+			signature = hex.EncodeToString(envelope.Signature)
+		}
+
+		var (
+			first    explorerAction
+			readSet  []explorerReadSet
+			writeSet []explorerWriteSet
+		)
+		if common.HeaderType(header.Type) == common.HeaderType_ENDORSER_TRANSACTION {
+			actions, err := tx.Actions()
+			if err != nil {
+				logrus.Errorf("failed to get actions from transaction: %s", err)
+			}
+			for j, action := range actions {
+				if j == 0 {
+					first = newExplorerAction(&action)
+				}
+				reads, writes := explorerRWSets(&action)
+				readSet = append(readSet, reads...)
+				writeSet = append(writeSet, writes...)
+			}
+		}
+		chaincode := first.chaincode
+		if chaincode.name != "" {
+			chaincodes[chaincode]++
+		}
+		chaincodeID := ""
+		if chaincode.name != "" {
+			chaincodeID = chaincode.name + ":" + chaincode.version
+		}
+		reads, err := json.Marshal(readSet)
+		if err != nil {
+			logrus.Errorf("failed to marshal read set: %s", err)
+		}
+		writes, err := json.Marshal(writeSet)
+		if err != nil {
+			logrus.Errorf("failed to marshal write set: %s", err)
+		}
+		rows = append(rows, []interface{}{
+			int64(data.BlockNumber), txID, createdAt, chaincode.name, first.status, creatorMSP, first.endorsers, chaincodeID,
+			common.HeaderType(header.Type).String(), string(reads), string(writes), genesisHash, tx.ValidationStatus(), signature,
+			string(creatorCert), nonce, first.input, first.proposal, s.network,
+		})
+	}
+	return rows, chaincodes
+}
+
+// newExplorerAction returns the fields of the transaction row taken from the action.
+func newExplorerAction(action *blocklib.Action) explorerAction {
+	var fields explorerAction
+	if chaincodeAction, err := action.ChaincodeAction(); err == nil {
+		if chaincodeAction.ChaincodeId != nil {
+			fields.chaincode = explorerChaincode{name: chaincodeAction.ChaincodeId.Name, version: chaincodeAction.ChaincodeId.Version}
+		}
+		if chaincodeAction.Response != nil {
+			fields.status = &chaincodeAction.Response.Status
+		}
+	}
+	var mspIDs []string
+	if endorsers, err := action.Endorsers(); err == nil {
+		for _, endorser := range endorsers {
+			mspIDs = append(mspIDs, endorser.Mspid)
+		}
+	} else {
+		logrus.Errorf("failed to get endorsers: %s", err)
+	}
+	input, err := action.ChaincodeInput()
+	if err != nil {
+		logrus.Errorf("failed to get chaincode input: %s", err)
+	}
+	if hash, err := action.ProposalHash(); err == nil {
+		fields.proposal = hex.EncodeToString(hash)
+	}
+	fields.endorsers = "{" + strings.Join(mspIDs, ",") + "}"
+	fields.input = strings.Join(input, ",")
+	return fields
+}
+
+func explorerRWSets(action *blocklib.Action) ([]explorerReadSet, []explorerWriteSet) {
+	rwsets, err := action.RWSets()
+	if err != nil {
+		logrus.Errorf("failed to extract rwsets: %s", err)
+		return nil, nil
+	}
+	var (
+		reads  []explorerReadSet
+		writes []explorerWriteSet
+	)
+	for _, rwset := range rwsets {
+		read := explorerReadSet{Chaincode: rwset.NameSpace, Set: []explorerKeyRead{}}
+		for _, kvRead := range rwset.KVRWSet.Reads {
+			keyRead := explorerKeyRead{Key: kvRead.Key}
+			if kvRead.Version != nil {
+				keyRead.Version = &explorerVersion{BlockNum: kvRead.Version.BlockNum, TxNum: kvRead.Version.TxNum}
+			}
+			read.Set = append(read.Set, keyRead)
+		}
+		write := explorerWriteSet{Chaincode: rwset.NameSpace, Set: []explorerKeyWrite{}}
+		for _, kvWrite := range rwset.KVRWSet.Writes {
+			write.Set = append(write.Set, explorerKeyWrite{Key: kvWrite.Key, IsDelete: kvWrite.IsDelete, Value: string(kvWrite.Value)})
+		}
+		reads = append(reads, read)
+		writes = append(writes, write)
+	}
+	return reads, writes
+}
+
+// explorerBlockHash returns hex-encoded header hash of the block.
+func explorerBlockHash(data *parser.Data) string {
+	hash := sha256.Sum256(blocklib.BlockHeaderBytes(&common.BlockHeader{
+		Number:       data.BlockNumber,
+		PreviousHash: data.Prevhash,
+		DataHash:     data.Datahash,
+	}))
+	return hex.EncodeToString(hash[:])
+}
+
+// explorerCreated returns the timestamp of the first transaction of the block as Explorer does.
+func explorerCreated(data *parser.Data) time.Time {
+	txs := explorerTxs(data)
+	for i := range txs {
+		if timestamp, err := txs[i].Timestamp(); err == nil {
+			return timestamp
+		}
+	}
+	return time.Time{}
+}
+
+// explorerTxs returns the transactions of the block saved to Explorer: the config transaction of config blocks.
+func explorerTxs(data *parser.Data) []blocklib.Tx {
+	if len(data.Txs) == 0 && data.ConfigTx != nil {
+		return []blocklib.Tx{*data.ConfigTx}
+	}
+	return data.Txs
+}
+
+// Checkpoint returns the number of the last block of the channel saved to the database.
+func (s *ExplorerAdapter) Checkpoint(channel string) (uint64, bool, error) {
+	genesisHash, err := s.GenesisHash(channel)
+	if err != nil {
+		return 0, false, err
+	}
+	var checkpoint sql.NullInt64
+	statement := rebind(s.dialect, "SELECT MAX(blocknum) FROM blocks WHERE channel_genesis_hash = ? AND network_name = ?")
+	if err = s.db.QueryRow(statement, genesisHash, s.network).Scan(&checkpoint); err != nil {
+		return 0, false, err
+	}
+	return uint64(checkpoint.Int64), checkpoint.Valid, nil
+}
+
+// Retrieve is not supported, Explorer schema doesn't keep the data of the blocks.
+func (s *ExplorerAdapter) Retrieve(string) (*parser.Data, error) {
+	return nil, errExplorerRead
+}
+
+// ReadStream is not supported, Explorer schema doesn't keep the data of the blocks.
+func (s *ExplorerAdapter) ReadStream(string) (<-chan *parser.Data, <-chan error) {
+	return failedStream(errExplorerRead)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/newity/crawler/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExplorerAdapterInject(t *testing.T) {
+	db := newSQLite(t)
+	adapter := NewExplorerAdapter(db, SQLiteDialect{}, "test-network")
+	assert.NoError(t, adapter.InitSchema())
+	assert.NoError(t, adapter.InitSchema())
+
+	// blocks of the channel without genesis hash are not saved
+	assert.Error(t, adapter.Inject(parseBlock(t, "../blocklib/mock/sampleblock.pb")))
+	assert.NoError(t, adapter.RegisterChannel("mychannel", "genesishash", time.Now()))
+	for _, block := range []string{"sampleblock", "mvcc_read_conflict", "mvcc_read_conflict"} {
+		assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/"+block+".pb")))
+	}
+
+	assert.Equal(t, 2, countRows(t, db, "SELECT COUNT(*) FROM blocks WHERE channel_genesis_hash = 'genesishash' AND network_name = 'test-network'"))
+	assert.Equal(t, 6, countRows(t, db, "SELECT COUNT(*) FROM transactions WHERE channel_genesis_hash = 'genesishash'"))
+	var blocks, trans int
+	assert.NoError(t, db.QueryRow("SELECT blocks, trans FROM channel WHERE name = 'mychannel'").Scan(&blocks, &trans))
+	assert.Equal(t, 2, blocks)
+	assert.Equal(t, 6, trans)
+	// injected twice, block 35 is counted once
+	assert.Equal(t, 6, countRows(t, db, "SELECT SUM(txcount) FROM chaincodes WHERE name = 'fabcar' AND channel_genesis_hash = 'genesishash'"))
+
+	var (
+		txcount                                    int
+		prehash, blockhash                         string
+		status                                     int
+		creator, endorsers, code, writeSet, txType string
+	)
+	assert.NoError(t, db.QueryRow("SELECT txcount, prehash, blockhash FROM blocks WHERE blocknum = 35").Scan(&txcount, &prehash, &blockhash))
+	assert.Equal(t, 5, txcount)
+	assert.Len(t, prehash, 64)
+	assert.Len(t, blockhash, 64)
+	assert.NoError(t, db.QueryRow("SELECT status, creator_msp_id, endorser_msp_id, validation_code, write_set, type FROM transactions WHERE txhash = ?",
+		"23e7c409b6849a71e6b5d7767a4e6c7efcd4bafba02b932ca5e6559e4d050dea").Scan(&status, &creator, &endorsers, &code, &writeSet, &txType))
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "Org1MSP", creator)
+	assert.Contains(t, endorsers, "Org1MSP")
+	assert.Equal(t, "VALID", code)
+	assert.Equal(t, "ENDORSER_TRANSACTION", txType)
+	var writes []explorerWriteSet
+	assert.NoError(t, json.Unmarshal([]byte(writeSet), &writes))
+	found := false
+	for _, write := range writes {
+		for _, kv := range write.Set {
+			found = found || write.Chaincode == "fabcar" && kv.Key == "CAR11"
+		}
+	}
+	assert.True(t, found)
+	assert.Equal(t, 5, countRows(t, db, "SELECT COUNT(*) FROM transactions WHERE blockid = 35 AND validation_code = 'MVCC_READ_CONFLICT'"))
+
+	checkpoint, ok, err := adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(35), checkpoint)
+	_, err = adapter.Retrieve("mychannel/35")
+	assert.Error(t, err)
+}
+
+func TestExplorerAdapterConfigBlocks(t *testing.T) {
+	db := newSQLite(t)
+	adapter := NewExplorerAdapter(db, SQLiteDialect{}, "test-network")
+	assert.NoError(t, adapter.InitSchema())
+
+	// config transaction of block 0 dates the channel
+	genesis := parseBlock(t, "../blocklib/mock/forIntegrityCheck.pb")
+	genesis.BlockNumber = 0
+	assert.NoError(t, adapter.Inject(genesis))
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/config.pb")))
+
+	var (
+		blocks, trans int
+		created       time.Time
+	)
+	assert.NoError(t, db.QueryRow("SELECT blocks, trans, createdt FROM channel WHERE name = 'mychannel'").Scan(&blocks, &trans, &created))
+	assert.Equal(t, 2, blocks)
+	assert.Equal(t, 2, trans)
+	assert.False(t, created.IsZero())
+
+	var txcount int
+	assert.NoError(t, db.QueryRow("SELECT txcount, createdt FROM blocks WHERE blocknum = 3").Scan(&txcount, &created))
+	assert.Equal(t, 1, txcount)
+	assert.False(t, created.IsZero())
+	var (
+		txType string
+		status sql.NullInt64
+	)
+	assert.NoError(t, db.QueryRow("SELECT type, status, createdt FROM transactions WHERE blockid = 3").Scan(&txType, &status, &created))
+	assert.Equal(t, "CONFIG", txType)
+	assert.False(t, status.Valid)
+	assert.False(t, created.IsZero())
+}
+
+func TestExplorerAdapterGenesis(t *testing.T) {
+	db := newSQLite(t)
+	adapter := NewExplorerAdapter(db, SQLiteDialect{}, "test-network")
+	assert.NoError(t, adapter.InitSchema())
+
+	genesis := &parser.Data{Channel: "cc", BlockNumber: 0, Datahash: []byte{1, 2, 3}}
+	assert.NoError(t, adapter.Inject(genesis))
+	hash, err := adapter.GenesisHash("cc")
+	assert.NoError(t, err)
+	assert.Equal(t, explorerBlockHash(genesis), hash)
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/withevents.pb")))
+
+	// the channel is found by the adapter of the restarted crawler
+	restarted := NewExplorerAdapter(db, SQLiteDialect{}, "test-network")
+	hash, err = restarted.GenesisHash("cc")
+	assert.NoError(t, err)
+	assert.Equal(t, explorerBlockHash(genesis), hash)
+	checkpoint, _, err := restarted.Checkpoint("cc")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(64), checkpoint)
+	// channels of the other networks are not shared
+	_, err = NewExplorerAdapter(db, SQLiteDialect{}, "other-network").GenesisHash("cc")
+	assert.Error(t, err)
+
+	var blocks, trans int
+	assert.NoError(t, db.QueryRow("SELECT blocks, trans FROM channel WHERE name = 'cc'").Scan(&blocks, &trans))
+	assert.Equal(t, 2, blocks)
+	assert.Equal(t, 1, trans)
+}
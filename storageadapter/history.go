@@ -0,0 +1,198 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const historyPrefix = "history/"
+
+// KeyModification is a committed write (or delete) of the chaincode key.
+type KeyModification struct {
+	Namespace   string
+	Key         string
+	Value       []byte
+	IsDelete    bool
+	TxId        string
+	BlockNumber uint64
+	TxIndex     int
+	Timestamp   time.Time
+}
+
+// StateHistoryAdapter works as SimpleAdapter and additionally keeps every committed value of each chaincode key,
+// so the history of the key and the world state at any block can be queried without a peer.
+// Writes and deletes of valid transactions are saved by keys "history/<channel>/<namespace>/<key>/<block>/<tx>/<action>"
+// in the same storage batch with the block and its checkpoint. Writes to private data collections are not applied.
+type StateHistoryAdapter struct {
+	*SimpleAdapter
+	storage storage.Storage
+}
+
+func NewStateHistoryAdapter(stor storage.Storage, opts ...Option) *StateHistoryAdapter {
+	return &StateHistoryAdapter{SimpleAdapter: NewSimpleAdapter(stor, opts...), storage: stor}
+}
+
+func (s *StateHistoryAdapter) Inject(data *parser.Data) error {
+	batch := storage.NewBatch()
+	if err := s.inject(batch, data); err != nil {
+		return err
+	}
+	if err := s.applyWrites(batch, data); err != nil {
+		return err
+	}
+	return s.storage.WriteBatch(batch)
+}
+
+// applyWrites adds modifications of the keys made by valid transactions of the block to the batch.
+func (s *StateHistoryAdapter) applyWrites(batch *storage.Batch, data *parser.Data) error {
+	for i, tx := range data.Txs {
+		if !tx.IsValid() {
+			continue
+		}
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction id: %s", err)
+			continue
+		}
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to get actions from transaction: %s", err)
+			continue
+		}
+		for j, action := range actions {
+			rwsets, err := action.RWSets()
+			if err != nil {
+				logrus.Errorf("failed to extract rwsets: %s", err)
+				continue
+			}
+			for _, rwset := range rwsets {
+				for _, write := range rwset.KVRWSet.Writes {
+					modification := &KeyModification{
+						Namespace:   rwset.NameSpace,
+						Key:         write.Key,
+						Value:       write.Value,
+						IsDelete:    write.IsDelete,
+						TxId:        txID,
+						BlockNumber: data.BlockNumber,
+						TxIndex:     i,
+						Timestamp:   timestamp,
+					}
+					encoded, err := s.encode(modification)
+					if err != nil {
+						return err
+					}
+					batch.Put(fmt.Sprintf("%s%020d/%06d/%06d", historyKeyPrefix(data.Channel, rwset.NameSpace, write.Key), data.BlockNumber, i, j), encoded)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// History returns all the committed modifications of the key in order (as GetHistoryForKey of the chaincode shim does).
+func (s *StateHistoryAdapter) History(channel, namespace, key string) ([]*KeyModification, error) {
+	var modifications []*KeyModification
+	err := s.scan(storage.IterateOptions{Prefix: historyKeyPrefix(channel, namespace, key)}, func(modification *KeyModification) {
+		modifications = append(modifications, modification)
+	})
+	return modifications, err
+}
+
+// StateAt returns the value of the key after the block was committed.
+// storage.ErrNotFound is returned if the key didn't exist at the block or was deleted.
+func (s *StateHistoryAdapter) StateAt(channel, namespace, key string, block uint64) ([]byte, error) {
+	prefix := historyKeyPrefix(channel, namespace, key)
+	page, err := s.storage.Iterate(storage.IterateOptions{
+		Prefix:  prefix,
+		End:     fmt.Sprintf("%s%020d", prefix, block+1),
+		Reverse: true,
+		Limit:   1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(page.KVs) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	modification := &KeyModification{}
+	if err := s.decode(page.KVs[0].Value, modification); err != nil {
+		return nil, err
+	}
+	if modification.IsDelete {
+		return nil, storage.ErrNotFound
+	}
+	return modification.Value, nil
+}
+
+// StateSnapshotAt returns the last modifications of all the existing keys of the namespace after the block was committed, ordered by key.
+func (s *StateHistoryAdapter) StateSnapshotAt(channel, namespace string, block uint64) ([]*KeyModification, error) {
+	var (
+		snapshot []*KeyModification
+		last     *KeyModification
+	)
+	flush := func() {
+		if last != nil && !last.IsDelete {
+			snapshot = append(snapshot, last)
+		}
+		last = nil
+	}
+	err := s.scan(storage.IterateOptions{Prefix: historyPrefix + channel + "/" + url.PathEscape(namespace) + "/"}, func(modification *KeyModification) {
+		if last != nil && last.Key != modification.Key {
+			flush()
+		}
+		if modification.BlockNumber <= block {
+			last = modification
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	flush()
+	// escaped keys may be ordered differently
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Key < snapshot[j].Key
+	})
+	return snapshot, nil
+}
+
+// scan passes the modifications matching opts to fn in key order.
+func (s *StateHistoryAdapter) scan(opts storage.IterateOptions, fn func(modification *KeyModification)) error {
+	opts.Limit = readPageSize
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return err
+		}
+		for _, kv := range page.KVs {
+			modification := &KeyModification{}
+			if err := s.decode(kv.Value, modification); err != nil {
+				return err
+			}
+			fn(modification)
+		}
+		if page.Cursor == "" {
+			return nil
+		}
+		opts.Cursor = page.Cursor
+	}
+}
+
+// historyKeyPrefix returns prefix of the modifications of the key. Namespace and key are escaped,
+// so chaincode keys can contain '/' (and any other bytes, e.g. composite keys).
+func historyKeyPrefix(channel, namespace, key string) string {
+	return historyPrefix + channel + "/" + url.PathEscape(namespace) + "/" + url.PathEscape(key) + "/"
+}
@@ -0,0 +1,56 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/newity/crawler/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateHistoryAdapter(t *testing.T) {
+	adapter := NewStateHistoryAdapter(newBadger(t))
+	data := parseBlock(t, "../blocklib/mock/sampleblock.pb")
+	assert.NoError(t, adapter.Inject(data))
+	// transactions of the block are invalid, their writes are not applied
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb")))
+	// the same write committed again later
+	data.BlockNumber = 40
+	assert.NoError(t, adapter.Inject(data))
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/withevents.pb")))
+
+	history, err := adapter.History("mychannel", "fabcar", "CAR11")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, uint64(7), history[0].BlockNumber)
+	assert.Equal(t, uint64(40), history[1].BlockNumber)
+	assert.Equal(t, "23e7c409b6849a71e6b5d7767a4e6c7efcd4bafba02b932ca5e6559e4d050dea", history[0].TxId)
+	assert.False(t, history[0].Timestamp.IsZero())
+
+	value, err := adapter.StateAt("mychannel", "fabcar", "CAR11", 35)
+	assert.NoError(t, err)
+	assert.Contains(t, string(value), `"owner":"Mary"`)
+	_, err = adapter.StateAt("mychannel", "fabcar", "CAR11", 6)
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	// composite keys contain zero bytes
+	swapKey := "\x00swaps\x00916c539010dd409ee661bf97184f9d0d8cd53f7f4e1ffa4b0b3d99f33b0834bd\x00"
+	history, err = adapter.History("cc", "cc", swapKey)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.True(t, history[0].IsDelete)
+	_, err = adapter.StateAt("cc", "cc", swapKey, 64)
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	snapshot, err := adapter.StateSnapshotAt("cc", "cc", 64)
+	assert.NoError(t, err)
+	assert.Len(t, snapshot, 1)
+	assert.Equal(t, "\x002c\x00rCo9JsHePV6VsDBCSSLgyAt7hPKZTFtFpSR5TFGYn6dTosx75\x00FIAT\x00", snapshot[0].Key)
+	assert.Equal(t, []byte("2"), snapshot[0].Value)
+	snapshot, err = adapter.StateSnapshotAt("cc", "cc", 63)
+	assert.NoError(t, err)
+	assert.Empty(t, snapshot)
+}
@@ -6,7 +6,9 @@ SPDX-License-Identifier: Apache-2.0
 package storageadapter
 
 import (
+	"github.com/newity/crawler/blocklib"
 	"github.com/newity/crawler/parser"
+	"time"
 )
 
 type StorageAdapter interface {
@@ -14,3 +16,43 @@ type StorageAdapter interface {
 	Retrieve(key string) (*parser.Data, error)
 	ReadStream(key string) (<-chan *parser.Data, <-chan error)
 }
+
+// BlockAdapter is implemented by storage adapters which keep blocks in per-channel namespaces.
+type BlockAdapter interface {
+	RetrieveBlock(channel string, number uint64) (*parser.Data, error)
+	ReadRange(channel string, from, to uint64) (<-chan *parser.Data, <-chan error)
+}
+
+// RecordAdapter is implemented by storage adapters which can save records emitted by parser.RecordParser.
+type RecordAdapter interface {
+	InjectRecords(records []*parser.Record) error
+	RetrieveRecord(key string) (*parser.Record, error)
+}
+
+// RollupAdapter is implemented by storage adapters which keep rollups of the throughput statistics (parser.Data.BlockStats).
+type RollupAdapter interface {
+	Rollups(channel, granularity string, from, to time.Time) ([]*parser.Rollup, error)
+}
+
+// IndexAdapter is implemented by storage adapters which maintain secondary indexes of the blocks.
+type IndexAdapter interface {
+	Tx(channel, txID string) (*blocklib.Tx, *TxLocation, error)
+	TxsByChaincode(channel, chaincode string, from, to uint64) ([]*TxLocation, error)
+	TxsByCreator(channel, mspID string, from, to uint64) ([]*TxLocation, error)
+	Events(channel, chaincode, name string, from, to uint64) ([]*IndexedEvent, error)
+}
+
+// HistoryAdapter is implemented by storage adapters which keep the history of the chaincode keys.
+type HistoryAdapter interface {
+	History(channel, namespace, key string) ([]*KeyModification, error)
+	StateAt(channel, namespace, key string, block uint64) ([]byte, error)
+	StateSnapshotAt(channel, namespace string, block uint64) ([]*KeyModification, error)
+}
+
+// WorldStateAdapter is implemented by storage adapters which maintain the current world state of the channels.
+type WorldStateAdapter interface {
+	State(channel, namespace, key string) ([]byte, error)
+	StateRange(channel, namespace, startKey, endKey string) ([]*KeyModification, error)
+	StateByPrefix(channel, namespace, prefix string) ([]*KeyModification, error)
+	QueryState(channel, namespace, query string) ([]*KeyModification, error)
+}
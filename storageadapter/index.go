@@ -0,0 +1,261 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/newity/crawler/blocklib"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/sirupsen/logrus"
+	"math"
+	"net/url"
+)
+
+const indexPrefix = "index/"
+
+// Kinds of the secondary indexes
+const (
+	indexTxID      = "txid"
+	indexChaincode = "chaincode"
+	indexEvent     = "event"
+	indexCreator   = "creator"
+)
+
+// TxLocation is an entry of the secondary index: position of the transaction (or its action) in the chain.
+type TxLocation struct {
+	Channel        string
+	BlockNumber    uint64
+	TxIndex        int // index of the transaction in parser.Data.Txs
+	ActionIndex    int // index of the action emitted the event (event index only), -1 otherwise
+	TxId           string
+	ValidationCode int32
+}
+
+// IndexedEvent is a chaincode event found by the event index.
+type IndexedEvent struct {
+	TxLocation
+	Event *peer.ChaincodeEvent
+}
+
+// IndexingAdapter works as SimpleAdapter and additionally maintains secondary indexes of the blocks in storage:
+// transaction ID, target chaincode, chaincode event name and creator MSP ID to the positions of the transactions.
+// Index entries are saved by keys "index/<channel>/<index>/<value>/<block>/<tx>[/<action>]" in the same storage batch
+// with the block and its checkpoint, so each value can be queried in block range.
+type IndexingAdapter struct {
+	*SimpleAdapter
+	storage storage.Storage
+}
+
+func NewIndexingAdapter(stor storage.Storage, opts ...Option) *IndexingAdapter {
+	return &IndexingAdapter{SimpleAdapter: NewSimpleAdapter(stor, opts...), storage: stor}
+}
+
+func (s *IndexingAdapter) Inject(data *parser.Data) error {
+	batch := storage.NewBatch()
+	if err := s.inject(batch, data); err != nil {
+		return err
+	}
+	if err := s.index(batch, data); err != nil {
+		return err
+	}
+	return s.storage.WriteBatch(batch)
+}
+
+// index adds index entries of the block transactions and events to the batch.
+func (s *IndexingAdapter) index(batch *storage.Batch, data *parser.Data) error {
+	for i, tx := range data.Txs {
+		location := TxLocation{
+			Channel:        data.Channel,
+			BlockNumber:    data.BlockNumber,
+			TxIndex:        i,
+			ActionIndex:    -1,
+			ValidationCode: tx.ValidationCode(),
+		}
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction id: %s", err)
+			continue
+		}
+		location.TxId = txID
+		if err = s.putIndex(batch, location, indexTxID, txID); err != nil {
+			return err
+		}
+
+		if mspID, _, err := tx.Creator(); err != nil {
+			logrus.Errorf("failed to get transaction creator: %s", err)
+		} else if err = s.putIndex(batch, location, indexCreator, mspID); err != nil {
+			return err
+		}
+		if chaincode, err := tx.ChaincodeId(); err != nil {
+			logrus.Errorf("failed to get transaction chaincode: %s", err)
+		} else if chaincode != nil && chaincode.Name != "" {
+			if err = s.putIndex(batch, location, indexChaincode, chaincode.Name); err != nil {
+				return err
+			}
+		}
+
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to get actions from transaction: %s", err)
+			continue
+		}
+		for j, action := range actions {
+			event, err := action.ChaincodeEvent()
+			if err != nil {
+				logrus.Errorf("failed to extract chaincode events: %s", err)
+				continue
+			}
+			if event.EventName == "" {
+				continue
+			}
+			eventLocation := location
+			eventLocation.ActionIndex = j
+			if err = s.putIndex(batch, eventLocation, indexEvent, event.ChaincodeId, event.EventName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TxByID returns location of the transaction with the ID. If there are several transactions with the ID
+// (the duplicates are invalidated by peers), the valid one is returned. storage.ErrNotFound is returned if there are none.
+func (s *IndexingAdapter) TxByID(channel, txID string) (*TxLocation, error) {
+	locations, err := s.lookup(channel, 0, math.MaxUint64, indexTxID, txID)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	for _, location := range locations {
+		if location.ValidationCode == int32(peer.TxValidationCode_VALID) {
+			return location, nil
+		}
+	}
+	return locations[0], nil
+}
+
+// Tx returns the transaction with the ID (see TxByID) with its location.
+func (s *IndexingAdapter) Tx(channel, txID string) (*blocklib.Tx, *TxLocation, error) {
+	location, err := s.TxByID(channel, txID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.RetrieveBlock(channel, location.BlockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if location.TxIndex >= len(data.Txs) {
+		return nil, nil, fmt.Errorf("block %d has no transaction %d", location.BlockNumber, location.TxIndex)
+	}
+	return &data.Txs[location.TxIndex], location, nil
+}
+
+// TxsByChaincode returns locations of the transactions invoking the chaincode in the blocks from 'from' to 'to' (inclusive).
+func (s *IndexingAdapter) TxsByChaincode(channel, chaincode string, from, to uint64) ([]*TxLocation, error) {
+	return s.lookup(channel, from, to, indexChaincode, chaincode)
+}
+
+// TxsByCreator returns locations of the transactions created by members of the MSP in the blocks from 'from' to 'to' (inclusive).
+func (s *IndexingAdapter) TxsByCreator(channel, mspID string, from, to uint64) ([]*TxLocation, error) {
+	return s.lookup(channel, from, to, indexCreator, mspID)
+}
+
+// EventLocations returns locations of the events of the chaincode with the name in the blocks from 'from' to 'to' (inclusive).
+func (s *IndexingAdapter) EventLocations(channel, chaincode, name string, from, to uint64) ([]*TxLocation, error) {
+	return s.lookup(channel, from, to, indexEvent, chaincode, name)
+}
+
+// Events returns the events of the chaincode with the name in the blocks from 'from' to 'to' (inclusive).
+func (s *IndexingAdapter) Events(channel, chaincode, name string, from, to uint64) ([]*IndexedEvent, error) {
+	locations, err := s.EventLocations(channel, chaincode, name, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		events []*IndexedEvent
+		data   *parser.Data
+	)
+	for _, location := range locations {
+		if data == nil || data.BlockNumber != location.BlockNumber {
+			if data, err = s.RetrieveBlock(channel, location.BlockNumber); err != nil {
+				return nil, err
+			}
+		}
+		if location.TxIndex >= len(data.Txs) {
+			return nil, fmt.Errorf("block %d has no transaction %d", location.BlockNumber, location.TxIndex)
+		}
+		actions, err := data.Txs[location.TxIndex].Actions()
+		if err != nil {
+			return nil, err
+		}
+		if location.ActionIndex >= len(actions) {
+			return nil, fmt.Errorf("transaction %s has no action %d", location.TxId, location.ActionIndex)
+		}
+		event, err := actions[location.ActionIndex].ChaincodeEvent()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &IndexedEvent{TxLocation: *location, Event: event})
+	}
+	return events, nil
+}
+
+// lookup returns index entries with the value in the blocks from 'from' to 'to' (inclusive) in order.
+func (s *IndexingAdapter) lookup(channel string, from, to uint64, kind string, value ...string) ([]*TxLocation, error) {
+	prefix := indexValuePrefix(channel, kind, value...)
+	opts := storage.IterateOptions{
+		Prefix: prefix,
+		Start:  fmt.Sprintf("%s%020d", prefix, from),
+		Limit:  readPageSize,
+	}
+	if to < math.MaxUint64 {
+		opts.End = fmt.Sprintf("%s%020d", prefix, to+1)
+	}
+	var locations []*TxLocation
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range page.KVs {
+			location := &TxLocation{}
+			if err := s.decode(kv.Value, location); err != nil {
+				return nil, err
+			}
+			locations = append(locations, location)
+		}
+		if page.Cursor == "" {
+			return locations, nil
+		}
+		opts.Cursor = page.Cursor
+	}
+}
+
+func (s *IndexingAdapter) putIndex(batch *storage.Batch, location TxLocation, kind string, value ...string) error {
+	encoded, err := s.encode(&location)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s%020d/%06d", indexValuePrefix(location.Channel, kind, value...), location.BlockNumber, location.TxIndex)
+	if location.ActionIndex >= 0 {
+		key += fmt.Sprintf("/%06d", location.ActionIndex)
+	}
+	batch.Put(key, encoded)
+	return nil
+}
+
+// indexValuePrefix returns prefix of the index entries with the value. Values are escaped, so they can contain '/'.
+func indexValuePrefix(channel, kind string, value ...string) string {
+	prefix := indexPrefix + channel + "/" + kind + "/"
+	for _, v := range value {
+		prefix += url.PathEscape(v) + "/"
+	}
+	return prefix
+}
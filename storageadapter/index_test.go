@@ -0,0 +1,75 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func parseBlock(t *testing.T, pathToBlock string) *parser.Data {
+	file, err := ioutil.ReadFile(pathToBlock)
+	assert.NoError(t, err)
+	block := &common.Block{}
+	assert.NoError(t, proto.Unmarshal(file, block))
+	data, err := parser.New().Parse(block)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestIndexingAdapter(t *testing.T) {
+	adapter := NewIndexingAdapter(newBadger(t))
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/sampleblock.pb")))
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb")))
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/withevents.pb")))
+
+	tx, location, err := adapter.Tx("mychannel", "944ef7c2e21e0991169fe0e8ef5a8b5bb629a442482dcb3e31416b7af9309f5a")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(35), location.BlockNumber)
+	assert.Equal(t, 2, location.TxIndex)
+	assert.Equal(t, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), location.ValidationCode)
+	txID, err := tx.TxId()
+	assert.NoError(t, err)
+	assert.Equal(t, location.TxId, txID)
+
+	_, _, err = adapter.Tx("cc", "944ef7c2e21e0991169fe0e8ef5a8b5bb629a442482dcb3e31416b7af9309f5a")
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	locations, err := adapter.TxsByChaincode("mychannel", "fabcar", 0, math.MaxUint64)
+	assert.NoError(t, err)
+	assert.Len(t, locations, 6)
+	assert.Equal(t, uint64(7), locations[0].BlockNumber)
+	locations, err = adapter.TxsByChaincode("mychannel", "fabcar", 8, 100)
+	assert.NoError(t, err)
+	assert.Len(t, locations, 5)
+
+	locations, err = adapter.TxsByCreator("mychannel", "Org1MSP", 0, 7)
+	assert.NoError(t, err)
+	assert.Len(t, locations, 1)
+	assert.Equal(t, "23e7c409b6849a71e6b5d7767a4e6c7efcd4bafba02b932ca5e6559e4d050dea", locations[0].TxId)
+	locations, err = adapter.TxsByCreator("cc", "atomyzeMSP", 0, math.MaxUint64)
+	assert.NoError(t, err)
+	assert.Len(t, locations, 1)
+
+	events, err := adapter.Events("cc", "cc", "key", 0, math.MaxUint64)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, uint64(64), events[0].BlockNumber)
+	assert.Equal(t, 0, events[0].ActionIndex)
+	assert.Equal(t, "key", events[0].Event.EventName)
+	assert.Len(t, events[0].Event.Payload, 75)
+
+	events, err = adapter.Events("cc", "cc", "other", 0, math.MaxUint64)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+)
+
+// UpgradeFunc upgrades the stored value of one schema version to the next one. The value is decoded
+// into the current type (e.g. *parser.Data, *parser.Record, *parser.Rollup, *TxLocation or *KeyModification),
+// so the function fills or converts the fields which layout has changed.
+type UpgradeFunc func(value interface{}) error
+
+// Migrations keeps upgrade functions of the stored values by schema version. Adapters configured with migrations
+// (see WithMigrations) upgrade values of the older versions lazily on read and write new values with Version,
+// MigrateStorage rewrites the whole storage offline.
+type Migrations struct {
+	upgrades map[byte]UpgradeFunc
+}
+
+func NewMigrations() *Migrations {
+	return &Migrations{upgrades: make(map[byte]UpgradeFunc)}
+}
+
+// Register registers the function which upgrades values of schema version 'from' to version from+1.
+func (m *Migrations) Register(from byte, upgrade UpgradeFunc) *Migrations {
+	m.upgrades[from] = upgrade
+	return m
+}
+
+// Version returns the schema version the values are upgraded to: the version following the last registered upgrade,
+// SchemaVersion if there are no upgrades.
+func (m *Migrations) Version() byte {
+	version := SchemaVersion
+	if m == nil {
+		return version
+	}
+	for from := range m.upgrades {
+		if from >= version {
+			version = from + 1
+		}
+	}
+	return version
+}
+
+// Upgrade upgrades the value of the schema version to Version by the registered functions in order.
+// Values of the newer versions are not supported.
+func (m *Migrations) Upgrade(version byte, value interface{}) error {
+	current := m.Version()
+	if version > current {
+		return fmt.Errorf("value has schema version %d, newer than %d", version, current)
+	}
+	for ; version < current; version++ {
+		upgrade, ok := m.upgrades[version]
+		if !ok {
+			// versions without layout changes (e.g. untagged values)
+			continue
+		}
+		if err := upgrade(value); err != nil {
+			return fmt.Errorf("failed to upgrade value from schema version %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// migratedPrefixes maps prefixes of the keys of the stored values to the types of the values.
+// Checkpoints and config blocks are not encoded by codecs and are not migrated.
+var migratedPrefixes = []struct {
+	prefix   string
+	newValue func() interface{}
+}{
+	{blockPrefix, func() interface{} { return &parser.Data{} }},
+	{recordPrefix, func() interface{} { return &parser.Record{} }},
+	{statsPrefix, func() interface{} { return &parser.Rollup{} }},
+	{indexPrefix, func() interface{} { return &TxLocation{} }},
+	{historyPrefix, func() interface{} { return &KeyModification{} }},
+	{statePrefix, func() interface{} { return &KeyModification{} }},
+}
+
+// MigrateStorage rewrites the whole storage offline, so it can be read without lazy upgrades: it moves blocks
+// saved by bare numbers into the namespaces of their channels (see SimpleAdapter.Migrate), upgrades values
+// of the older schema versions with the migrations of the options and re-encodes values which are untagged
+// or encoded with another codec than the codec of the options. Values are rewritten in batches of at most readPageSize values
+// and writeBatchSize bytes, so the migration can be interrupted and started again. MigrateStorage returns the number of the rewritten values.
+// The crawler must not write to the storage during the migration.
+func MigrateStorage(stor storage.Storage, opts ...Option) (int, error) {
+	adapter := NewSimpleAdapter(stor, opts...)
+	migrated, err := adapter.Migrate()
+	if err != nil {
+		return migrated, err
+	}
+	for _, kind := range migratedPrefixes {
+		rewritten, err := adapter.rewrite(kind.prefix, kind.newValue)
+		migrated += rewritten
+		if err != nil {
+			return migrated, err
+		}
+	}
+	return migrated, nil
+}
+
+// rewrite rewrites outdated values with the prefix and returns the number of the rewritten values.
+func (s *SimpleAdapter) rewrite(prefix string, newValue func() interface{}) (int, error) {
+	var (
+		rewritten, updated int
+		batch              = storage.NewBatch()
+	)
+	flush := func() error {
+		if updated == 0 {
+			return nil
+		}
+		if err := s.storage.WriteBatch(batch); err != nil {
+			return err
+		}
+		rewritten += updated
+		batch, updated = storage.NewBatch(), 0
+		return nil
+	}
+	version := s.migrations.Version()
+	opts := storage.IterateOptions{Prefix: prefix, Limit: readPageSize}
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return rewritten, err
+		}
+		for _, kv := range page.KVs {
+			value := newValue()
+			if err = s.decode(kv.Value, value); err != nil {
+				return rewritten, fmt.Errorf("failed to decode value %s: %w", kv.Key, err)
+			}
+			encoded, err := s.encode(value)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to encode value %s: %w", kv.Key, err)
+			}
+			if isTagged(kv.Value) && kv.Value[1] == encoded[1] && kv.Value[2] == version {
+				continue
+			}
+			if batch.Size()+len(kv.Key)+len(encoded) > writeBatchSize {
+				if err = flush(); err != nil {
+					return rewritten, err
+				}
+			}
+			batch.Put(kv.Key, encoded)
+			updated++
+		}
+		if err = flush(); err != nil {
+			return rewritten, err
+		}
+		if page.Cursor == "" {
+			return rewritten, nil
+		}
+		opts.Cursor = page.Cursor
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+// fillBlockStats is the upgrade of parser.Data to schema version 2 in the tests: blocks of version 1 get BlockStats.
+func fillBlockStats(value interface{}) error {
+	if data, ok := value.(*parser.Data); ok && data.BlockStats == nil {
+		data.BlockStats = &parser.BlockStats{Channel: data.Channel, BlockNumber: data.BlockNumber, Txs: len(data.Txs)}
+	}
+	return nil
+}
+
+func TestMigrationsUpgradeOnRead(t *testing.T) {
+	stor := newBadger(t)
+	assert.NoError(t, NewSimpleAdapter(stor).Inject(parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb")))
+
+	migrations := NewMigrations().Register(SchemaVersion, fillBlockStats)
+	assert.Equal(t, SchemaVersion+1, migrations.Version())
+	adapter := NewSimpleAdapter(stor, WithMigrations(migrations))
+	data, err := adapter.RetrieveBlock("mychannel", 35)
+	assert.NoError(t, err)
+	assert.Equal(t, &parser.BlockStats{Channel: "mychannel", BlockNumber: 35, Txs: 5}, data.BlockStats)
+	// the stored value is not rewritten on read
+	value, err := stor.Get(blockKey("mychannel", 35))
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion, value[2])
+
+	// new values are written with the version of the migrations and not upgraded again
+	data.BlockNumber, data.BlockStats = 36, &parser.BlockStats{Channel: "mychannel", BlockNumber: 36, Txs: 7}
+	assert.NoError(t, adapter.Inject(data))
+	value, err = stor.Get(blockKey("mychannel", 36))
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion+1, value[2])
+	data, err = adapter.RetrieveBlock("mychannel", 36)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, data.BlockStats.Txs)
+
+	// adapter without the migrations doesn't read the values of the newer version
+	_, err = NewSimpleAdapter(stor).RetrieveBlock("mychannel", 36)
+	assert.Error(t, err)
+}
+
+func TestMigrateStorage(t *testing.T) {
+	stor := newBadger(t)
+	// untagged values saved by the previous versions
+	legacy, err := GobCodec{}.Marshal(&parser.Data{Channel: "mychannel", BlockNumber: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, stor.Put("1", legacy))
+	record := &parser.Record{ID: parser.RecordID{Channel: "mychannel", BlockNumber: 1, Type: parser.RecordTx}, TxId: "tx"}
+	legacy, err = GobCodec{}.Marshal(record)
+	assert.NoError(t, err)
+	assert.NoError(t, stor.Put(recordPrefix+record.ID.Key(), legacy))
+	// tagged value of the current version
+	assert.NoError(t, NewSimpleAdapter(stor).Inject(&parser.Data{Channel: "mychannel", BlockNumber: 2}))
+
+	migrations := NewMigrations().Register(SchemaVersion, fillBlockStats)
+	opts := []Option{WithCodec(JSONCodec{}), WithMigrations(migrations)}
+	migrated, err := MigrateStorage(stor, opts...)
+	assert.NoError(t, err)
+	// bare-number block is moved and re-encoded, block 2 and the record are re-encoded
+	assert.Equal(t, 4, migrated)
+	for _, key := range []string{blockKey("mychannel", 1), blockKey("mychannel", 2), recordPrefix + record.ID.Key()} {
+		value, err := stor.Get(key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, []byte{tagMagic, JSONCodecID, SchemaVersion + 1}, value[:tagLength], key)
+	}
+	value, err := stor.Get(blockKey("mychannel", 1))
+	assert.NoError(t, err)
+	data, err := Decode(value)
+	assert.NoError(t, err)
+	assert.Equal(t, &parser.BlockStats{Channel: "mychannel", BlockNumber: 1}, data.BlockStats)
+	assert.Equal(t, []uint64{1, 2}, readAll(t, NewSimpleAdapter(stor, opts...), "mychannel/0-"))
+
+	// migrated storage is not rewritten again
+	migrated, err = MigrateStorage(stor, opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
+
+// batchSizeStorage records the sizes of the written batches.
+type batchSizeStorage struct {
+	*storage.Badger
+	sizes []int
+}
+
+func (s *batchSizeStorage) WriteBatch(batch *storage.Batch) error {
+	s.sizes = append(s.sizes, batch.Size())
+	return s.Badger.WriteBatch(batch)
+}
+
+func TestMigrateStorageLargeBlocks(t *testing.T) {
+	stor := &batchSizeStorage{Badger: newBadger(t)}
+	// 30 blocks of 1MB are written in several batches
+	for number := uint64(0); number < 30; number++ {
+		legacy, err := GobCodec{}.Marshal(&parser.Data{Channel: "mychannel", BlockNumber: number, Datahash: make([]byte, 1<<20)})
+		assert.NoError(t, err)
+		assert.NoError(t, stor.Put(strconv.FormatUint(number, 10), legacy))
+	}
+	migrated, err := MigrateStorage(stor, WithCodec(JSONCodec{}))
+	assert.NoError(t, err)
+	// blocks are moved and re-encoded
+	assert.Equal(t, 60, migrated)
+	assert.True(t, len(stor.sizes) > 2)
+	for _, size := range stor.sizes {
+		assert.True(t, size <= writeBatchSize, size)
+	}
+	checkpoint, _, err := NewSimpleAdapter(stor).Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(29), checkpoint)
+	data, err := NewSimpleAdapter(stor).RetrieveBlock("mychannel", 29)
+	assert.NoError(t, err)
+	assert.Len(t, data.Datahash, 1<<20)
+}
@@ -11,29 +11,64 @@ import (
 	"sync"
 )
 
+// recordTopicSuffix is appended to the channel name to get the topic of its records.
+const recordTopicSuffix = ".records"
+
 // QueueAdapter is a general storage adapter for the message brokers
 type QueueAdapter struct {
+	*options
 	storage storage.Storage
 }
 
-func NewQueueAdapter(stor storage.Storage) *QueueAdapter {
-	return &QueueAdapter{stor}
+func NewQueueAdapter(stor storage.Storage, opts ...Option) *QueueAdapter {
+	return &QueueAdapter{options: applyOptions(opts), storage: stor}
 }
 
 func (s *QueueAdapter) Inject(data *parser.Data) error {
-	encoded, err := Encode(data)
+	encoded, err := s.encode(data)
 	if err != nil {
 		return err
 	}
 	return s.storage.Put(data.Channel, encoded)
 }
 
+// InjectRecords publishes records in order to the topic "<channel>.records", so they are not mixed
+// with parser.Data of the blocks published to the topic named after the channel.
+func (s *QueueAdapter) InjectRecords(records []*parser.Record) error {
+	batch := storage.NewBatch()
+	for _, record := range records {
+		encoded, err := s.encode(record)
+		if err != nil {
+			return err
+		}
+		batch.Put(record.ID.Channel+recordTopicSuffix, encoded)
+	}
+	return s.storage.WriteBatch(batch)
+}
+
+// RetrieveRecord reads one record from the records topic of the channel.
+func (s *QueueAdapter) RetrieveRecord(channel string) (*parser.Record, error) {
+	value, err := s.storage.Get(channel + recordTopicSuffix)
+	if err != nil {
+		return nil, err
+	}
+	record := &parser.Record{}
+	if err := s.decode(value, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
 func (s *QueueAdapter) Retrieve(topic string) (*parser.Data, error) {
 	value, err := s.storage.Get(topic)
 	if err != nil {
 		return nil, err
 	}
-	return Decode(value)
+	data := &parser.Data{}
+	if err := s.decode(value, data); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
 func (s *QueueAdapter) ReadStream(topic string) (<-chan *parser.Data, <-chan error) {
@@ -47,8 +82,8 @@ func (s *QueueAdapter) ReadStream(topic string) (<-chan *parser.Data, <-chan err
 		for {
 			select {
 			case msg := <-stream:
-				decodedMsg, err := Decode(msg)
-				if err != nil {
+				decodedMsg := &parser.Data{}
+				if err := s.decode(msg, decodedMsg); err != nil {
 					errOutChan <- err
 				}
 				out <- decodedMsg
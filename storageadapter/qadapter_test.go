@@ -0,0 +1,31 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/newity/crawler/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestQueueAdapterRecordTopic(t *testing.T) {
+	stor := newBadger(t)
+	adapter := NewQueueAdapter(stor)
+	assert.NoError(t, adapter.Inject(parseBlock(t, "../blocklib/mock/sampleblock.pb")))
+	record := &parser.Record{ID: parser.RecordID{Channel: "mychannel", BlockNumber: 7, Type: parser.RecordTx}, TxId: "tx"}
+	assert.NoError(t, adapter.InjectRecords([]*parser.Record{record}))
+
+	// records don't overwrite parser.Data of the channel topic
+	data, err := adapter.Retrieve("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), data.BlockNumber)
+	assert.Len(t, data.Txs, 1)
+	decoded, err := adapter.RetrieveRecord("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, "tx", decoded.TxId)
+	_, err = stor.Get("mychannel" + recordTopicSuffix)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// StateQuery is a rich query of JSON state values in the CouchDB (Mango) query syntax, e.g.
+// {"selector": {"owner": "Mary", "year": {"$gte": 2010}}, "limit": 10}.
+// Fields are matched by dot-separated paths, supported operators are $and, $or, $nor, $not, $eq, $ne, $gt, $gte, $lt, $lte,
+// $in, $nin, $exists and $regex. Results are ordered by key, sort and indexes are not supported.
+type StateQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Limit    int                    `json:"limit,omitempty"`
+}
+
+// ParseStateQuery parses rich query.
+func ParseStateQuery(query string) (*StateQuery, error) {
+	parsed := &StateQuery{}
+	if err := json.Unmarshal([]byte(query), parsed); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if parsed.Selector == nil {
+		return nil, fmt.Errorf("invalid query: no selector")
+	}
+	return parsed, nil
+}
+
+// Match checks that JSON value matches the selector of the query. Values which are not JSON objects never match.
+func (q *StateQuery) Match(value []byte) (bool, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return false, nil
+	}
+	return matchSelector(doc, q.Selector)
+}
+
+func matchSelector(doc map[string]interface{}, selector map[string]interface{}) (bool, error) {
+	for field, condition := range selector {
+		var (
+			matched bool
+			err     error
+		)
+		switch field {
+		case "$and", "$or", "$nor":
+			matched, err = matchCombination(doc, field, condition)
+		case "$not":
+			subselector, ok := condition.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("invalid query: $not requires selector")
+			}
+			matched, err = matchSelector(doc, subselector)
+			matched = !matched
+		default:
+			value, exists := lookupField(doc, field)
+			matched, err = matchCondition(value, exists, condition)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchCombination(doc map[string]interface{}, operator string, condition interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("invalid query: %s requires array of selectors", operator)
+	}
+	matches := 0
+	for _, s := range selectors {
+		subselector, ok := s.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("invalid query: %s requires array of selectors", operator)
+		}
+		matched, err := matchSelector(doc, subselector)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			matches++
+		}
+	}
+	switch operator {
+	case "$and":
+		return matches == len(selectors), nil
+	case "$or":
+		return matches > 0, nil
+	default:
+		return matches == 0, nil
+	}
+}
+
+// lookupField returns value of the field specified by dot-separated path.
+func lookupField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func matchCondition(value interface{}, exists bool, condition interface{}) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return exists && reflect.DeepEqual(value, condition), nil
+	}
+	if !hasOperators(operators) {
+		// nested selector
+		object, ok := value.(map[string]interface{})
+		if !exists || !ok {
+			return false, nil
+		}
+		return matchSelector(object, operators)
+	}
+
+	for operator, argument := range operators {
+		var matched bool
+		switch operator {
+		case "$eq":
+			matched = exists && reflect.DeepEqual(value, argument)
+		case "$ne":
+			matched = !exists || !reflect.DeepEqual(value, argument)
+		case "$gt", "$gte", "$lt", "$lte":
+			cmp, ok := compareValues(value, argument)
+			if !exists || !ok {
+				return false, nil
+			}
+			switch operator {
+			case "$gt":
+				matched = cmp > 0
+			case "$gte":
+				matched = cmp >= 0
+			case "$lt":
+				matched = cmp < 0
+			default:
+				matched = cmp <= 0
+			}
+		case "$in", "$nin":
+			candidates, ok := argument.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("invalid query: %s requires array", operator)
+			}
+			found := false
+			for _, candidate := range candidates {
+				if exists && reflect.DeepEqual(value, candidate) {
+					found = true
+					break
+				}
+			}
+			matched = found == (operator == "$in")
+		case "$exists":
+			expected, ok := argument.(bool)
+			if !ok {
+				return false, fmt.Errorf("invalid query: $exists requires boolean")
+			}
+			matched = exists == expected
+		case "$regex":
+			pattern, ok := argument.(string)
+			if !ok {
+				return false, fmt.Errorf("invalid query: $regex requires string")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, fmt.Errorf("invalid query: %w", err)
+			}
+			s, ok := value.(string)
+			matched = exists && ok && re.MatchString(s)
+		case "$not":
+			notMatched, err := matchCondition(value, exists, argument)
+			if err != nil {
+				return false, err
+			}
+			matched = !notMatched
+		default:
+			return false, fmt.Errorf("invalid query: unsupported operator %s", operator)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func hasOperators(condition map[string]interface{}) bool {
+	for key := range condition {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// compareValues compares numbers with numbers and strings with strings.
+func compareValues(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < b:
+			return -1, true
+		case a > b:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a, b), true
+	}
+	return 0, false
+}
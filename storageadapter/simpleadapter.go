@@ -6,35 +6,371 @@ SPDX-License-Identifier: Apache-2.0
 package storageadapter
 
 import (
+	"fmt"
 	"github.com/newity/crawler/parser"
 	"github.com/newity/crawler/storage"
+	"math"
 	"strconv"
+	"strings"
 )
 
+const (
+	blockPrefix      = "block/"
+	recordPrefix     = "record/"
+	checkpointPrefix = "checkpoint/"
+)
+
+// readPageSize is the number of blocks read from storage at once while streaming or migrating.
+const readPageSize = 100
+
+// writeBatchSize is the number of bytes of the keys and values written to storage at once while migrating,
+// so each batch fits into one storage transaction (see badger.ErrTxnTooBig).
+const writeBatchSize = 4 << 20
+
+// SimpleAdapter saves parser.Data by keys "block/<channel>/<zero-padded block number>" and records by their keys,
+// so blocks of different channels don't overwrite each other and blocks of each channel are ordered by number.
+// Each block is saved together with the checkpoint of its channel (the greatest number of the saved blocks) in one storage batch.
+// Databases created by the previous versions keep blocks by bare numbers, use Migrate to move them into channel namespaces.
 type SimpleAdapter struct {
+	*options
 	storage storage.Storage
 }
 
-func NewSimpleAdapter(stor storage.Storage) *SimpleAdapter {
-	return &SimpleAdapter{stor}
+func NewSimpleAdapter(stor storage.Storage, opts ...Option) *SimpleAdapter {
+	return &SimpleAdapter{options: applyOptions(opts), storage: stor}
 }
 
 func (s *SimpleAdapter) Inject(data *parser.Data) error {
-	encoded, err := Encode(data)
+	batch := storage.NewBatch()
+	if err := s.inject(batch, data); err != nil {
+		return err
+	}
+	return s.storage.WriteBatch(batch)
+}
+
+// inject adds writes of the block data and the checkpoint of its channel to the batch.
+func (s *SimpleAdapter) inject(batch *storage.Batch, data *parser.Data) error {
+	encoded, err := s.encode(data)
+	if err != nil {
+		return err
+	}
+	batch.Put(blockKey(data.Channel, data.BlockNumber), encoded)
+	return s.putCheckpoint(batch, data.Channel, data.BlockNumber)
+}
+
+// putCheckpoint adds the checkpoint of the channel to the batch. The checkpoint never moves backwards:
+// if the block is older than the saved checkpoint (e.g. it is injected again), the checkpoint is kept.
+func (s *SimpleAdapter) putCheckpoint(batch *storage.Batch, channel string, number uint64) error {
+	checkpoint, ok, err := s.Checkpoint(channel)
 	if err != nil {
 		return err
 	}
-	return s.storage.Put(strconv.Itoa(int(data.BlockNumber)), encoded)
+	if ok && checkpoint >= number {
+		return nil
+	}
+	batch.Put(checkpointPrefix+channel, []byte(strconv.FormatUint(number, 10)))
+	return nil
+}
+
+// Retrieve retrieves parser.Data by key "<channel>/<blocknum>".
+// Bare block number is looked up as is, so blocks can be read from databases which are not migrated yet.
+// Blocks saved by the current version are found by bare number only if the storage has blocks of a single channel,
+// otherwise the error asks for the channel.
+func (s *SimpleAdapter) Retrieve(key string) (*parser.Data, error) {
+	channel, blocknum, ok := splitChannel(key)
+	if !ok {
+		value, err := s.storage.Get(key)
+		if err == storage.ErrNotFound && isBareNumber(key) {
+			return s.retrieveBare(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		data := &parser.Data{}
+		if err := s.decode(value, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	number, err := strconv.ParseUint(blocknum, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number %s: %w", blocknum, err)
+	}
+	return s.RetrieveBlock(channel, number)
+}
+
+// retrieveBare retrieves block by bare number from the namespace of the only channel of the storage.
+func (s *SimpleAdapter) retrieveBare(blocknum string) (*parser.Data, error) {
+	channels, err := s.Channels()
+	if err != nil {
+		return nil, err
+	}
+	switch len(channels) {
+	case 0:
+		return nil, storage.ErrNotFound
+	case 1:
+		return s.Retrieve(channels[0] + "/" + blocknum)
+	default:
+		return nil, fmt.Errorf("block %s is ambiguous, blocks are saved by channels (%s): use key <channel>/%s",
+			blocknum, strings.Join(channels, ", "), blocknum)
+	}
+}
+
+// RetrieveBlock retrieves parser.Data of the block of the channel.
+func (s *SimpleAdapter) RetrieveBlock(channel string, number uint64) (*parser.Data, error) {
+	value, err := s.storage.Get(blockKey(channel, number))
+	if err != nil {
+		return nil, err
+	}
+	data := &parser.Data{}
+	if err := s.decode(value, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Checkpoint returns the number of the last block of the channel saved to storage.
+func (s *SimpleAdapter) Checkpoint(channel string) (uint64, bool, error) {
+	return s.readCheckpoint(checkpointPrefix + channel)
+}
+
+// readCheckpoint reads the block number saved by the key, false is returned if the key is not found.
+func (s *SimpleAdapter) readCheckpoint(key string) (uint64, bool, error) {
+	value, err := s.storage.Get(key)
+	if err == storage.ErrNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	checkpoint, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return checkpoint, true, nil
+}
+
+// Channels returns the channels which have blocks or records saved to storage (by their checkpoints).
+func (s *SimpleAdapter) Channels() ([]string, error) {
+	var channels []string
+	opts := storage.IterateOptions{Prefix: checkpointPrefix, Limit: readPageSize}
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range page.KVs {
+			channels = append(channels, strings.TrimPrefix(kv.Key, checkpointPrefix))
+		}
+		if page.Cursor == "" {
+			return channels, nil
+		}
+		opts.Cursor = page.Cursor
+	}
 }
 
-func (s *SimpleAdapter) Retrieve(blocknum string) (*parser.Data, error) {
-	value, err := s.storage.Get(blocknum)
+// InjectRecords saves each record by its key prefixed with "record/".
+// Records are saved with the checkpoints of their channels in one storage batch.
+func (s *SimpleAdapter) InjectRecords(records []*parser.Record) error {
+	batch := storage.NewBatch()
+	checkpoints := make(map[string]uint64)
+	for _, record := range records {
+		encoded, err := s.encode(record)
+		if err != nil {
+			return err
+		}
+		batch.Put(recordPrefix+record.ID.Key(), encoded)
+		if record.ID.BlockNumber >= checkpoints[record.ID.Channel] {
+			checkpoints[record.ID.Channel] = record.ID.BlockNumber
+		}
+	}
+	for channel, checkpoint := range checkpoints {
+		if err := s.putCheckpoint(batch, channel, checkpoint); err != nil {
+			return err
+		}
+	}
+	return s.storage.WriteBatch(batch)
+}
+
+// RetrieveRecord retrieves record by its key (parser.RecordID.Key).
+func (s *SimpleAdapter) RetrieveRecord(key string) (*parser.Record, error) {
+	value, err := s.storage.Get(recordPrefix + key)
 	if err != nil {
 		return nil, err
 	}
-	return Decode(value)
+	record := &parser.Record{}
+	if err := s.decode(value, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadStream streams parser.Data of the blocks of the channel in range specified as "<channel>/<from>-<to>" (inclusive),
+// "<channel>/<from>-" (up to the last stored block) or "<channel>/<blocknum>".
+// Blocks missing in storage are skipped, the data channel is closed when the range is over.
+func (s *SimpleAdapter) ReadStream(key string) (<-chan *parser.Data, <-chan error) {
+	channel, blockRange, ok := splitChannel(key)
+	if !ok {
+		return failedStream(fmt.Errorf("invalid block range %s: expected <channel>/<range>", key))
+	}
+	from, to, err := parseBlockRange(blockRange)
+	if err != nil {
+		return failedStream(err)
+	}
+	return s.ReadRange(channel, from, to)
+}
+
+// ReadRange streams parser.Data of the blocks of the channel from 'from' to 'to' (inclusive) in order.
+func (s *SimpleAdapter) ReadRange(channel string, from, to uint64) (<-chan *parser.Data, <-chan error) {
+	out, errChan := make(chan *parser.Data), make(chan error, 1)
+	go func() {
+		defer close(out)
+		opts := storage.IterateOptions{
+			Prefix: blockPrefix + channel + "/",
+			Start:  blockKey(channel, from),
+			Limit:  readPageSize,
+		}
+		if to < math.MaxUint64 {
+			opts.End = blockKey(channel, to+1)
+		}
+		for {
+			page, err := s.storage.Iterate(opts)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			for _, kv := range page.KVs {
+				data := &parser.Data{}
+				if err := s.decode(kv.Value, data); err != nil {
+					errChan <- err
+					return
+				}
+				out <- data
+			}
+			if page.Cursor == "" {
+				return
+			}
+			opts.Cursor = page.Cursor
+		}
+	}()
+	return out, errChan
 }
 
-func (s *SimpleAdapter) ReadStream(blocknum string) (<-chan *parser.Data, <-chan error) {
-	return nil, nil
+// Migrate moves blocks saved by bare numbers (by the previous versions of the adapter) into the namespaces of their channels
+// and updates checkpoints of the channels. Blocks are moved in batches of at most readPageSize blocks and writeBatchSize bytes,
+// so the migration can be interrupted and started again. Migrate returns the number of the moved blocks.
+func (s *SimpleAdapter) Migrate() (int, error) {
+	var (
+		migrated, moved int
+		batch           = storage.NewBatch()
+		checkpoints     = make(map[string]uint64)
+	)
+	// flush writes the moved blocks together with the checkpoints of their channels
+	flush := func() error {
+		if moved == 0 {
+			return nil
+		}
+		for channel, checkpoint := range checkpoints {
+			if err := s.putCheckpoint(batch, channel, checkpoint); err != nil {
+				return err
+			}
+		}
+		if err := s.storage.WriteBatch(batch); err != nil {
+			return err
+		}
+		migrated += moved
+		batch, checkpoints, moved = storage.NewBatch(), make(map[string]uint64), 0
+		return nil
+	}
+	// bare numbers are the keys from "0" to "9..." and ':' follows '9'
+	opts := storage.IterateOptions{Start: "0", End: ":", Limit: readPageSize}
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return migrated, err
+		}
+		for _, kv := range page.KVs {
+			if !isBareNumber(kv.Key) {
+				continue
+			}
+			data := &parser.Data{}
+			if err := s.decode(kv.Value, data); err != nil {
+				return migrated, fmt.Errorf("failed to decode block %s: %w", kv.Key, err)
+			}
+			if data.Channel == "" {
+				return migrated, fmt.Errorf("block %s has no channel", kv.Key)
+			}
+			if batch.Size()+len(kv.Key)+len(kv.Value) > writeBatchSize {
+				if err = flush(); err != nil {
+					return migrated, err
+				}
+			}
+			batch.Put(blockKey(data.Channel, data.BlockNumber), kv.Value)
+			batch.Delete(kv.Key)
+			moved++
+			if data.BlockNumber >= checkpoints[data.Channel] {
+				checkpoints[data.Channel] = data.BlockNumber
+			}
+		}
+		if err = flush(); err != nil {
+			return migrated, err
+		}
+		if page.Cursor == "" {
+			return migrated, nil
+		}
+		opts.Cursor = page.Cursor
+	}
+}
+
+func blockKey(channel string, number uint64) string {
+	return fmt.Sprintf("%s%s/%020d", blockPrefix, channel, number)
+}
+
+// splitChannel splits key "<channel>/<rest>" (channel names can't contain '/').
+func splitChannel(key string) (string, string, bool) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func failedStream(err error) (<-chan *parser.Data, <-chan error) {
+	errChan := make(chan error, 1)
+	errChan <- err
+	out := make(chan *parser.Data)
+	close(out)
+	return out, errChan
+}
+
+func parseBlockRange(blockRange string) (uint64, uint64, error) {
+	bounds := strings.SplitN(blockRange, "-", 2)
+	from, err := strconv.ParseUint(bounds[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid block range %s: %w", blockRange, err)
+	}
+	if len(bounds) == 1 {
+		return from, from, nil
+	}
+	if bounds[1] == "" {
+		return from, math.MaxUint64, nil
+	}
+	to, err := strconv.ParseUint(bounds[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid block range %s: %w", blockRange, err)
+	}
+	return from, to, nil
+}
+
+// isBareNumber checks that key is a block number saved by the previous versions of the adapter.
+func isBareNumber(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
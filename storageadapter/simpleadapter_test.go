@@ -0,0 +1,161 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func readAll(t *testing.T, adapter *SimpleAdapter, blockRange string) []uint64 {
+	stream, errChan := adapter.ReadStream(blockRange)
+	var numbers []uint64
+	for data := range stream {
+		numbers = append(numbers, data.BlockNumber)
+	}
+	assert.Len(t, errChan, 0)
+	return numbers
+}
+
+func TestSimpleAdapterReadStream(t *testing.T) {
+	stor := newBadger(t)
+	adapter := NewSimpleAdapter(stor)
+	for _, number := range []uint64{0, 1, 2, 9, 10, 11, 99, 100, 101, 250, 1000, 1001} {
+		assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: number}))
+	}
+	// blocks of other channels and records are skipped
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel2", BlockNumber: 10}))
+	assert.NoError(t, adapter.InjectRecords([]*parser.Record{{ID: parser.RecordID{Channel: "mychannel", BlockNumber: 100}}}))
+
+	assert.Equal(t, []uint64{9, 10, 11, 99, 100, 101, 250}, readAll(t, adapter, "mychannel/9-250"))
+	assert.Equal(t, []uint64{250, 1000, 1001}, readAll(t, adapter, "mychannel/200-"))
+	assert.Equal(t, []uint64{0, 1, 2, 9, 10, 11, 99, 100, 101, 250, 1000, 1001}, readAll(t, adapter, "mychannel/0-"))
+	assert.Equal(t, []uint64{100}, readAll(t, adapter, "mychannel/100"))
+	assert.Equal(t, []uint64{10}, readAll(t, adapter, "mychannel2/0-"))
+	assert.Empty(t, readAll(t, adapter, "mychannel/3-8"))
+
+	for _, blockRange := range []string{"mychannel/abc", "0-10"} {
+		stream, errChan := adapter.ReadStream(blockRange)
+		_, ok := <-stream
+		assert.False(t, ok)
+		assert.Error(t, <-errChan)
+	}
+}
+
+func TestSimpleAdapterChannels(t *testing.T) {
+	adapter := NewSimpleAdapter(newBadger(t))
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 5, Prevhash: []byte("a")}))
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel2", BlockNumber: 5, Prevhash: []byte("b")}))
+
+	data, err := adapter.RetrieveBlock("mychannel", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), data.Prevhash)
+	data, err = adapter.Retrieve("mychannel2/5")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("b"), data.Prevhash)
+	_, err = adapter.RetrieveBlock("mychannel", 6)
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	channels, err := adapter.Channels()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mychannel", "mychannel2"}, channels)
+
+	// bare number is ambiguous with several channels
+	_, err = adapter.Retrieve("5")
+	assert.EqualError(t, err, "block 5 is ambiguous, blocks are saved by channels (mychannel, mychannel2): use key <channel>/5")
+
+	single := NewSimpleAdapter(newBadger(t))
+	_, err = single.Retrieve("5")
+	assert.Equal(t, storage.ErrNotFound, err)
+	assert.NoError(t, single.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 5, Prevhash: []byte("a")}))
+	data, err = single.Retrieve("5")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), data.Prevhash)
+	_, err = single.Retrieve("6")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestSimpleAdapterMigrate(t *testing.T) {
+	stor := newBadger(t)
+	adapter := NewSimpleAdapter(stor)
+	// blocks saved by bare numbers
+	for number := uint64(0); number < 150; number++ {
+		encoded, err := Encode(&parser.Data{Channel: "mychannel", BlockNumber: number})
+		assert.NoError(t, err)
+		assert.NoError(t, stor.Put(strconv.FormatUint(number, 10), encoded))
+	}
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 200}))
+	assert.NoError(t, adapter.InjectRecords([]*parser.Record{{ID: parser.RecordID{Channel: "otherchannel", BlockNumber: 1}}}))
+
+	// legacy keys are retrieved as is before migration
+	data, err := adapter.Retrieve("42")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), data.BlockNumber)
+
+	migrated, err := adapter.Migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, 150, migrated)
+
+	_, err = stor.Get("42")
+	assert.Equal(t, storage.ErrNotFound, err)
+	data, err = adapter.RetrieveBlock("mychannel", 42)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), data.BlockNumber)
+	assert.Len(t, readAll(t, adapter, "mychannel/0-"), 151)
+	checkpoint, _, err := adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), checkpoint)
+	_, err = adapter.RetrieveRecord(parser.RecordID{Channel: "otherchannel", BlockNumber: 1}.Key())
+	assert.NoError(t, err)
+
+	// nothing to do on the second run
+	migrated, err = adapter.Migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
+
+func TestSimpleAdapterCheckpoint(t *testing.T) {
+	adapter := NewSimpleAdapter(newBadger(t))
+	_, ok, err := adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 7}))
+	checkpoint, ok, err := adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), checkpoint)
+
+	assert.NoError(t, adapter.InjectRecords([]*parser.Record{
+		{ID: parser.RecordID{Channel: "mychannel", BlockNumber: 8, ActionIndex: -1, Type: parser.RecordTx}},
+		{ID: parser.RecordID{Channel: "otherchannel", BlockNumber: 3, ActionIndex: -1, Type: parser.RecordTx}},
+	}))
+	checkpoint, _, err = adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), checkpoint)
+	checkpoint, _, err = adapter.Checkpoint("otherchannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), checkpoint)
+
+	// replaying older blocks and records doesn't move the checkpoints backwards
+	for _, replaying := range []StorageAdapter{adapter, NewIndexingAdapter(adapter.storage), NewStateHistoryAdapter(adapter.storage)} {
+		assert.NoError(t, replaying.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 5}))
+	}
+	assert.NoError(t, adapter.InjectRecords([]*parser.Record{
+		{ID: parser.RecordID{Channel: "otherchannel", BlockNumber: 1, ActionIndex: -1, Type: parser.RecordTx}},
+	}))
+	checkpoint, _, err = adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), checkpoint)
+	checkpoint, _, err = adapter.Checkpoint("otherchannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), checkpoint)
+	_, err = adapter.RetrieveBlock("mychannel", 5)
+	assert.NoError(t, err)
+}
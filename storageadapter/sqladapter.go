@@ -0,0 +1,401 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/sirupsen/logrus"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlSchema creates the tables of SQLAdapter. $blob and $timestamp are replaced with the column types of the dialect.
+// Keys of the state writes are binary: composite keys of the chaincodes contain \x00, which is not allowed in PostgreSQL text.
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS blocks (
+	channel TEXT NOT NULL,
+	block_number BIGINT NOT NULL,
+	prev_hash $blob,
+	data_hash $blob,
+	tx_count INTEGER NOT NULL,
+	data $blob NOT NULL,
+	PRIMARY KEY (channel, block_number)
+)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+	channel TEXT NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_index INTEGER NOT NULL,
+	tx_id TEXT NOT NULL,
+	type TEXT NOT NULL,
+	creator_msp_id TEXT NOT NULL,
+	created_at $timestamp,
+	validation_code INTEGER NOT NULL,
+	validation_status TEXT NOT NULL,
+	PRIMARY KEY (channel, block_number, tx_index),
+	FOREIGN KEY (channel, block_number) REFERENCES blocks (channel, block_number) ON DELETE CASCADE
+)`,
+	`CREATE INDEX IF NOT EXISTS transactions_tx_id ON transactions (channel, tx_id)`,
+	`CREATE INDEX IF NOT EXISTS transactions_creator ON transactions (channel, creator_msp_id)`,
+	`CREATE TABLE IF NOT EXISTS actions (
+	channel TEXT NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_index INTEGER NOT NULL,
+	action_index INTEGER NOT NULL,
+	chaincode TEXT NOT NULL,
+	PRIMARY KEY (channel, block_number, tx_index, action_index),
+	FOREIGN KEY (channel, block_number, tx_index) REFERENCES transactions (channel, block_number, tx_index) ON DELETE CASCADE
+)`,
+	`CREATE INDEX IF NOT EXISTS actions_chaincode ON actions (channel, chaincode)`,
+	`CREATE TABLE IF NOT EXISTS endorsements (
+	channel TEXT NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_index INTEGER NOT NULL,
+	action_index INTEGER NOT NULL,
+	endorsement_index INTEGER NOT NULL,
+	msp_id TEXT NOT NULL,
+	signature $blob,
+	PRIMARY KEY (channel, block_number, tx_index, action_index, endorsement_index),
+	FOREIGN KEY (channel, block_number, tx_index, action_index) REFERENCES actions (channel, block_number, tx_index, action_index) ON DELETE CASCADE
+)`,
+	`CREATE INDEX IF NOT EXISTS endorsements_msp_id ON endorsements (channel, msp_id)`,
+	`CREATE TABLE IF NOT EXISTS events (
+	channel TEXT NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_index INTEGER NOT NULL,
+	action_index INTEGER NOT NULL,
+	chaincode TEXT NOT NULL,
+	event_name TEXT NOT NULL,
+	payload $blob,
+	PRIMARY KEY (channel, block_number, tx_index, action_index),
+	FOREIGN KEY (channel, block_number, tx_index, action_index) REFERENCES actions (channel, block_number, tx_index, action_index) ON DELETE CASCADE
+)`,
+	`CREATE INDEX IF NOT EXISTS events_name ON events (channel, chaincode, event_name)`,
+	`CREATE TABLE IF NOT EXISTS state_writes (
+	channel TEXT NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_index INTEGER NOT NULL,
+	action_index INTEGER NOT NULL,
+	write_index INTEGER NOT NULL,
+	namespace TEXT NOT NULL,
+	state_key $blob NOT NULL,
+	value $blob,
+	is_delete BOOLEAN NOT NULL,
+	PRIMARY KEY (channel, block_number, tx_index, action_index, write_index),
+	FOREIGN KEY (channel, block_number, tx_index, action_index) REFERENCES actions (channel, block_number, tx_index, action_index) ON DELETE CASCADE
+)`,
+	`CREATE INDEX IF NOT EXISTS state_writes_key ON state_writes (channel, namespace, state_key)`,
+}
+
+// sqlColumns are the columns of the tables of SQLAdapter in the order of the values of sqlRow.
+var sqlColumns = map[string][]string{
+	"blocks":       {"channel", "block_number", "prev_hash", "data_hash", "tx_count", "data"},
+	"transactions": {"channel", "block_number", "tx_index", "tx_id", "type", "creator_msp_id", "created_at", "validation_code", "validation_status"},
+	"actions":      {"channel", "block_number", "tx_index", "action_index", "chaincode"},
+	"endorsements": {"channel", "block_number", "tx_index", "action_index", "endorsement_index", "msp_id", "signature"},
+	"events":       {"channel", "block_number", "tx_index", "action_index", "chaincode", "event_name", "payload"},
+	"state_writes": {"channel", "block_number", "tx_index", "action_index", "write_index", "namespace", "state_key", "value", "is_delete"},
+}
+
+// sqlBlockTables are the tables with the rows of the block, children first.
+var sqlBlockTables = []string{"state_writes", "events", "endorsements", "actions", "transactions"}
+
+type sqlRow struct {
+	table  string
+	values []interface{}
+}
+
+// SQLAdapter saves parser.Data into relational schema through database/sql: blocks, their transactions,
+// actions of the transactions, endorsements and chaincode events of the actions and writes to the world state
+// (writes to private data collections are not saved). Tables are keyed by channel and block number (and positions
+// of the rows in the block), transactions are indexed by ID and creator MSP ID, actions by chaincode, endorsements by MSP ID,
+// events by chaincode and event name and state writes by namespace and key.
+// parser.Data of the block is kept in blocks.data encoded with the codec of the adapter, so blocks can be retrieved back.
+// Inject is idempotent: the block row is upserted and the rows of its transactions are replaced in one database transaction.
+type SQLAdapter struct {
+	*options
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLAdapter creates SQLAdapter over the database opened with the driver of the dialect. Create the schema with InitSchema.
+func NewSQLAdapter(db *sql.DB, dialect SQLDialect, opts ...Option) *SQLAdapter {
+	return &SQLAdapter{options: applyOptions(opts), db: db, dialect: dialect}
+}
+
+// InitSchema creates the tables and indexes which don't exist yet.
+func (s *SQLAdapter) InitSchema() error {
+	types := strings.NewReplacer("$blob", s.dialect.BlobType(), "$timestamp", s.dialect.TimestampType())
+	for _, statement := range sqlSchema {
+		if _, err := s.db.Exec(types.Replace(statement)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLAdapter) Inject(data *parser.Data) error {
+	encoded, err := s.encode(data)
+	if err != nil {
+		return err
+	}
+	rows := append([]sqlRow{{"blocks", []interface{}{
+		data.Channel, int64(data.BlockNumber), data.Prevhash, data.Datahash, len(data.Txs), encoded,
+	}}}, blockRows(data)...)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = s.replaceRows(tx, data, rows); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logrus.Errorf("failed to rollback transaction: %s", rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// replaceRows deletes the rows of the block and saves the new ones, the block row is upserted.
+func (s *SQLAdapter) replaceRows(tx *sql.Tx, data *parser.Data, rows []sqlRow) error {
+	for _, table := range sqlBlockTables {
+		statement := rebind(s.dialect, "DELETE FROM "+table+" WHERE channel = ? AND block_number = ?")
+		if _, err := tx.Exec(statement, data.Channel, int64(data.BlockNumber)); err != nil {
+			return fmt.Errorf("failed to delete rows from %s: %w", table, err)
+		}
+	}
+	statements := make(map[string]*sql.Stmt)
+	for _, row := range rows {
+		stmt, ok := statements[row.table]
+		if !ok {
+			var conflict []string
+			if row.table == "blocks" {
+				conflict = []string{"channel", "block_number"}
+			}
+			var err error
+			stmt, err = tx.Prepare(rebind(s.dialect, insertStatement(row.table, sqlColumns[row.table], conflict...)))
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+			statements[row.table] = stmt
+		}
+		if _, err := stmt.Exec(row.values...); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", row.table, err)
+		}
+	}
+	return nil
+}
+
+// blockRows returns the rows of the transactions of the block and their actions in the order of the foreign keys.
+func blockRows(data *parser.Data) []sqlRow {
+	var rows []sqlRow
+	channel, number := data.Channel, int64(data.BlockNumber)
+	for i, tx := range data.Txs {
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction id: %s", err)
+			continue
+		}
+		header, err := tx.ChannelHeader()
+		if err != nil {
+			logrus.Errorf("failed to get channel header: %s", err)
+			continue
+		}
+		creator, _, err := tx.Creator()
+		if err != nil {
+			logrus.Errorf("failed to get transaction creator: %s", err)
+		}
+		var createdAt *time.Time
+		if timestamp, err := tx.Timestamp(); err == nil {
+			createdAt = &timestamp
+		}
+		rows = append(rows, sqlRow{"transactions", []interface{}{
+			channel, number, i, txID, common.HeaderType(header.Type).String(), creator, createdAt, tx.ValidationCode(), tx.ValidationStatus(),
+		}})
+		if common.HeaderType(header.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+			continue
+		}
+
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to get actions from transaction: %s", err)
+			continue
+		}
+		for j, action := range actions {
+			chaincode := ""
+			if chaincodeAction, err := action.ChaincodeAction(); err == nil && chaincodeAction.ChaincodeId != nil {
+				chaincode = chaincodeAction.ChaincodeId.Name
+			}
+			rows = append(rows, sqlRow{"actions", []interface{}{channel, number, i, j, chaincode}})
+
+			for k, endorsement := range action.Endorsements() {
+				endorser := &msp.SerializedIdentity{}
+				if err := proto.Unmarshal(endorsement.Endorser, endorser); err != nil {
+					logrus.Errorf("failed to unmarshal endorser: %s", err)
+				}
+				rows = append(rows, sqlRow{"endorsements", []interface{}{channel, number, i, j, k, endorser.Mspid, endorsement.Signature}})
+			}
+
+			event, err := action.ChaincodeEvent()
+			if err != nil {
+				logrus.Errorf("failed to get chaincode event: %s", err)
+			} else if event.EventName != "" {
+				rows = append(rows, sqlRow{"events", []interface{}{channel, number, i, j, event.ChaincodeId, event.EventName, event.Payload}})
+			}
+
+			rwsets, err := action.RWSets()
+			if err != nil {
+				logrus.Errorf("failed to extract rwsets: %s", err)
+				continue
+			}
+			k := 0
+			for _, rwset := range rwsets {
+				for _, write := range rwset.KVRWSet.Writes {
+					rows = append(rows, sqlRow{"state_writes", []interface{}{
+						channel, number, i, j, k, rwset.NameSpace, []byte(write.Key), write.Value, write.IsDelete,
+					}})
+					k++
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// Retrieve retrieves parser.Data by key "<channel>/<blocknum>".
+func (s *SQLAdapter) Retrieve(key string) (*parser.Data, error) {
+	channel, blocknum, ok := splitChannel(key)
+	if !ok {
+		return nil, fmt.Errorf("invalid block key %s: expected <channel>/<blocknum>", key)
+	}
+	number, err := strconv.ParseUint(blocknum, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number %s: %w", blocknum, err)
+	}
+	return s.RetrieveBlock(channel, number)
+}
+
+// RetrieveBlock retrieves parser.Data of the block of the channel. storage.ErrNotFound is returned if the block is not saved.
+func (s *SQLAdapter) RetrieveBlock(channel string, number uint64) (*parser.Data, error) {
+	var value []byte
+	statement := rebind(s.dialect, "SELECT data FROM blocks WHERE channel = ? AND block_number = ?")
+	err := s.db.QueryRow(statement, channel, int64(number)).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := &parser.Data{}
+	if err := s.decode(value, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Checkpoint returns the number of the last block of the channel saved to the database.
+func (s *SQLAdapter) Checkpoint(channel string) (uint64, bool, error) {
+	var checkpoint sql.NullInt64
+	statement := rebind(s.dialect, "SELECT MAX(block_number) FROM blocks WHERE channel = ?")
+	if err := s.db.QueryRow(statement, channel).Scan(&checkpoint); err != nil {
+		return 0, false, err
+	}
+	return uint64(checkpoint.Int64), checkpoint.Valid, nil
+}
+
+// Channels returns the channels which have blocks saved to the database.
+func (s *SQLAdapter) Channels() ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT channel FROM blocks ORDER BY channel")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var channels []string
+	for rows.Next() {
+		var channel string
+		if err = rows.Scan(&channel); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+// ReadStream streams parser.Data of the blocks of the channel in range specified as in SimpleAdapter.ReadStream.
+func (s *SQLAdapter) ReadStream(key string) (<-chan *parser.Data, <-chan error) {
+	channel, blockRange, ok := splitChannel(key)
+	if !ok {
+		return failedStream(fmt.Errorf("invalid block range %s: expected <channel>/<range>", key))
+	}
+	from, to, err := parseBlockRange(blockRange)
+	if err != nil {
+		return failedStream(err)
+	}
+	return s.ReadRange(channel, from, to)
+}
+
+// ReadRange streams parser.Data of the blocks of the channel from 'from' to 'to' (inclusive) in order.
+// Blocks are read by pages of readPageSize blocks.
+func (s *SQLAdapter) ReadRange(channel string, from, to uint64) (<-chan *parser.Data, <-chan error) {
+	out, errChan := make(chan *parser.Data), make(chan error, 1)
+	if to > math.MaxInt64 {
+		to = math.MaxInt64
+	}
+	statement := rebind(s.dialect, "SELECT block_number, data FROM blocks WHERE channel = ? AND block_number >= ? AND block_number <= ? "+
+		"ORDER BY block_number LIMIT "+strconv.Itoa(readPageSize))
+	go func() {
+		defer close(out)
+		next := int64(from)
+		for {
+			page, last, err := s.readPage(statement, channel, next, int64(to))
+			if err != nil {
+				errChan <- err
+				return
+			}
+			for _, data := range page {
+				out <- data
+			}
+			if len(page) < readPageSize || last == math.MaxInt64 {
+				return
+			}
+			next = last + 1
+		}
+	}()
+	return out, errChan
+}
+
+// readPage reads page of the blocks and returns the number of the last read block.
+func (s *SQLAdapter) readPage(statement, channel string, from, to int64) ([]*parser.Data, int64, error) {
+	rows, err := s.db.Query(statement, channel, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var (
+		page []*parser.Data
+		last int64
+	)
+	for rows.Next() {
+		var value []byte
+		if err = rows.Scan(&last, &value); err != nil {
+			return nil, 0, err
+		}
+		data := &parser.Data{}
+		if err := s.decode(value, data); err != nil {
+			return nil, 0, err
+		}
+		page = append(page, data)
+	}
+	return page, last, rows.Err()
+}
@@ -0,0 +1,110 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SQLDialect adapts the statements of the SQL adapters to the database.
+// Statements are written with '?' placeholders which are rebound by the dialect.
+type SQLDialect interface {
+	// Name identifies the dialect
+	Name() string
+	// Placeholder returns placeholder of the n-th (starting with 1) parameter of the statement
+	Placeholder(n int) string
+	// BlobType returns column type of binary data
+	BlobType() string
+	// TimestampType returns column type of timestamps
+	TimestampType() string
+	// SerialType returns column type of auto-incremented integer primary key
+	SerialType() string
+}
+
+// SQLiteDialect is the dialect of SQLite (3.24 or newer), e.g. with github.com/mattn/go-sqlite3 driver.
+// Foreign keys are enforced by SQLite only with "_foreign_keys=1" option (PRAGMA foreign_keys = ON).
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+func (SQLiteDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (SQLiteDialect) BlobType() string {
+	return "BLOB"
+}
+
+func (SQLiteDialect) TimestampType() string {
+	return "TIMESTAMP"
+}
+
+func (SQLiteDialect) SerialType() string {
+	return "INTEGER PRIMARY KEY AUTOINCREMENT"
+}
+
+// PostgresDialect is the dialect of PostgreSQL (9.5 or newer), e.g. with github.com/lib/pq or github.com/jackc/pgx drivers.
+type PostgresDialect struct{}
+
+func (PostgresDialect) Name() string {
+	return "postgres"
+}
+
+func (PostgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (PostgresDialect) BlobType() string {
+	return "BYTEA"
+}
+
+func (PostgresDialect) TimestampType() string {
+	return "TIMESTAMP WITH TIME ZONE"
+}
+
+func (PostgresDialect) SerialType() string {
+	return "SERIAL PRIMARY KEY"
+}
+
+// rebind replaces '?' placeholders of the statement with the placeholders of the dialect.
+func rebind(dialect SQLDialect, statement string) string {
+	var rebound strings.Builder
+	n := 0
+	for _, c := range statement {
+		if c != '?' {
+			rebound.WriteRune(c)
+			continue
+		}
+		n++
+		rebound.WriteString(dialect.Placeholder(n))
+	}
+	return rebound.String()
+}
+
+// insertStatement returns INSERT statement of the columns of the table. If conflict columns are set,
+// the row with the same values of the conflict columns is updated (upsert).
+func insertStatement(table string, columns []string, conflict ...string) string {
+	statement := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	if len(conflict) == 0 {
+		return statement
+	}
+	isConflict := make(map[string]bool)
+	for _, column := range conflict {
+		isConflict[column] = true
+	}
+	var updates []string
+	for _, column := range columns {
+		if isConflict[column] {
+			continue
+		}
+		updates = append(updates, column+" = excluded."+column)
+	}
+	return statement + " ON CONFLICT (" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + strings.Join(updates, ", ")
+}
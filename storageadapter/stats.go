@@ -0,0 +1,145 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	statsPrefix           = "stats/"
+	statsCheckpointPrefix = "statscheckpoint/"
+	statsTimeFormat       = "20060102T150405Z"
+)
+
+// StatsAdapter works as SimpleAdapter and additionally keeps per-minute, per-hour and per-day rollups
+// of the throughput statistics (parser.Data.BlockStats, see parser.ThroughputParser) in storage.
+// Rollups are saved by keys "stats/<channel>/<granularity>/<period start>" in the same storage batch with the block and the stats checkpoint
+// of its channel ("statscheckpoint/<channel>", the last aggregated block), so blocks which are not newer than the stats checkpoint
+// are saved without being aggregated again (e.g. after restart).
+// As StateMirrorAdapter does, the rollups must start from block 0 and get the blocks of the channel in order: Inject fails
+// on the first block of the channel other than 0 and on the block which doesn't follow the stats checkpoint, so no block is missed.
+type StatsAdapter struct {
+	*SimpleAdapter
+	mu      sync.Mutex
+	storage storage.Storage
+}
+
+func NewStatsAdapter(stor storage.Storage, opts ...Option) *StatsAdapter {
+	return &StatsAdapter{SimpleAdapter: NewSimpleAdapter(stor, opts...), storage: stor}
+}
+
+func (s *StatsAdapter) Inject(data *parser.Data) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint, ok, err := s.StatsCheckpoint(data.Channel)
+	if err != nil {
+		return err
+	}
+	if !ok && data.BlockNumber > 0 {
+		return fmt.Errorf("stats of channel %s are not aggregated: start the aggregation from block 0", data.Channel)
+	}
+	if ok && data.BlockNumber > checkpoint+1 {
+		return fmt.Errorf("stats of channel %s are aggregated up to block %d: blocks %d-%d are missing",
+			data.Channel, checkpoint, checkpoint+1, data.BlockNumber-1)
+	}
+	batch := storage.NewBatch()
+	if err = s.inject(batch, data); err != nil {
+		return err
+	}
+	if ok && data.BlockNumber <= checkpoint {
+		// the block has been aggregated
+		return s.storage.WriteBatch(batch)
+	}
+
+	if data.BlockStats != nil && !data.BlockStats.CommitTime.IsZero() {
+		for _, granularity := range parser.Granularities {
+			rollup, err := s.Rollup(data.Channel, granularity, data.BlockStats.CommitTime)
+			if err != nil {
+				return err
+			}
+			rollup.Add(data.BlockStats)
+			encoded, err := s.encode(rollup)
+			if err != nil {
+				return err
+			}
+			batch.Put(rollupKey(data.Channel, granularity, rollup.Start), encoded)
+		}
+	}
+	batch.Put(statsCheckpointPrefix+data.Channel, []byte(strconv.FormatUint(data.BlockNumber, 10)))
+	return s.storage.WriteBatch(batch)
+}
+
+// StatsCheckpoint returns the number of the last block of the channel which is aggregated into the rollups.
+func (s *StatsAdapter) StatsCheckpoint(channel string) (uint64, bool, error) {
+	return s.readCheckpoint(statsCheckpointPrefix + channel)
+}
+
+// Rollup returns rollup of the channel for the period of granularity which contains moment.
+// Empty rollup is returned if there are no blocks in the period.
+func (s *StatsAdapter) Rollup(channel, granularity string, moment time.Time) (*parser.Rollup, error) {
+	rollup, err := parser.NewRollup(channel, granularity, moment)
+	if err != nil {
+		return nil, err
+	}
+	value, err := s.storage.Get(rollupKey(channel, granularity, rollup.Start))
+	if err == storage.ErrNotFound {
+		return rollup, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved := &parser.Rollup{}
+	if err := s.decode(value, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// Rollups returns non-empty rollups of the channel for all the periods of granularity between from and to (inclusive).
+// Rollups are iterated over in the order of the keys, statsTimeFormat keeps it chronological.
+func (s *StatsAdapter) Rollups(channel, granularity string, from, to time.Time) ([]*parser.Rollup, error) {
+	period, err := parser.GranularityDuration(granularity)
+	if err != nil {
+		return nil, err
+	}
+	opts := storage.IterateOptions{
+		Prefix: fmt.Sprintf("%s%s/%s/", statsPrefix, channel, granularity),
+		Start:  rollupKey(channel, granularity, from.UTC().Truncate(period)),
+		End:    rollupKey(channel, granularity, to.UTC().Truncate(period).Add(period)),
+		Limit:  readPageSize,
+	}
+	var rollups []*parser.Rollup
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range page.KVs {
+			rollup := &parser.Rollup{}
+			if err := s.decode(kv.Value, rollup); err != nil {
+				return nil, err
+			}
+			if rollup.Blocks > 0 {
+				rollups = append(rollups, rollup)
+			}
+		}
+		if page.Cursor == "" {
+			return rollups, nil
+		}
+		opts.Cursor = page.Cursor
+	}
+}
+
+func rollupKey(channel, granularity string, start time.Time) string {
+	return fmt.Sprintf("%s%s/%s/%s", statsPrefix, channel, granularity, start.UTC().Format(statsTimeFormat))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func newBadger(t *testing.T) *storage.Badger {
+	dir, err := ioutil.TempDir("", "crawler")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	stor, err := storage.NewBadger(dir)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		stor.Close()
+	})
+	return stor
+}
+
+func TestStatsAdapter(t *testing.T) {
+	adapter := NewStatsAdapter(newBadger(t))
+	// the aggregation starts from block 0
+	assert.Error(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 1}))
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 0}))
+
+	start := time.Date(2020, 11, 5, 10, 59, 30, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		commitTime := start.Add(time.Duration(i) * 20 * time.Second)
+		data := &parser.Data{Channel: "mychannel", BlockNumber: uint64(i + 1), BlockStats: &parser.BlockStats{
+			Channel:         "mychannel",
+			BlockNumber:     uint64(i + 1),
+			CommitTime:      commitTime,
+			Txs:             2,
+			ValidTxs:        1,
+			ValidationCodes: map[string]int{"VALID": 1, "MVCC_READ_CONFLICT": 1},
+			Bytes:           100,
+		}}
+		if i > 0 {
+			data.BlockStats.SinceLastBlock = 20 * time.Second
+		}
+		assert.NoError(t, adapter.Inject(data))
+	}
+	// the same block again is not aggregated twice
+	assert.NoError(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 4, BlockStats: &parser.BlockStats{BlockNumber: 4, CommitTime: start, Txs: 2}}))
+	// blocks 5 and 6 are missing
+	assert.Error(t, adapter.Inject(&parser.Data{Channel: "mychannel", BlockNumber: 7, BlockStats: &parser.BlockStats{BlockNumber: 7, CommitTime: start, Txs: 2}}))
+	checkpoint, _, err := adapter.StatsCheckpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), checkpoint)
+
+	// blocks at 10:59:30, 10:59:50, 11:00:10 and 11:00:30
+	minutes, err := adapter.Rollups("mychannel", parser.GranularityMinute, start, start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 2)
+	assert.Equal(t, 2, minutes[0].Blocks)
+	assert.Equal(t, uint64(1), minutes[0].FirstBlock)
+	assert.Equal(t, time.Date(2020, 11, 5, 11, 0, 0, 0, time.UTC), minutes[1].Start)
+	assert.Equal(t, uint64(3), minutes[1].FirstBlock)
+	assert.Equal(t, 20*time.Second, minutes[1].AvgBlockInterval())
+
+	hours, err := adapter.Rollups("mychannel", parser.GranularityHour, start, start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, hours, 2)
+	// the period which contains 'to' is included, long ranges don't cost a read per period
+	minutes, err = adapter.Rollups("mychannel", parser.GranularityMinute, start.AddDate(-10, 0, 0), start.Add(30*time.Second))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 2)
+	minutes, err = adapter.Rollups("mychannel", parser.GranularityMinute, start.Add(time.Minute), start.AddDate(10, 0, 0))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 1)
+
+	day, err := adapter.Rollup("mychannel", parser.GranularityDay, start)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, day.Blocks)
+	assert.Equal(t, 8, day.Txs)
+	assert.Equal(t, 0.5, day.ValidRatio())
+	assert.Equal(t, map[string]int{"VALID": 4, "MVCC_READ_CONFLICT": 4}, day.ValidationCodes)
+	assert.Equal(t, 3, day.Intervals)
+
+	empty, err := adapter.Rollup("otherchannel", parser.GranularityDay, start)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, empty.Blocks)
+
+	data, err := adapter.Retrieve("mychannel/2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), data.BlockNumber)
+}
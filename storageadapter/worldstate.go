@@ -0,0 +1,208 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"sync"
+)
+
+const (
+	statePrefix           = "state/"
+	stateCheckpointPrefix = "statecheckpoint/"
+)
+
+// StateMirrorAdapter works as SimpleAdapter and additionally maintains the current world state of each channel:
+// the last committed value of every chaincode key, so reporting jobs don't have to query peers.
+// Values are saved by keys "state/<channel>/<namespace>/<key>" in the same storage batch with the block and the state checkpoint
+// of its channel ("statecheckpoint/<channel>", the last block whose writes are applied), so the mirror always matches its checkpoint.
+// Blocks which are not newer than the state checkpoint are saved without applying their writes (e.g. after restart).
+// The mirror must start from block 0 and get the blocks of the channel in order: Inject fails on the first block
+// of the channel other than 0 and on the block which doesn't follow the state checkpoint, as their state is unknown.
+// The state checkpoint is kept apart from the block checkpoint, which may be moved by blocks saved without the mirror.
+// Writes to private data collections are not applied.
+type StateMirrorAdapter struct {
+	*SimpleAdapter
+	mu      sync.Mutex
+	storage storage.Storage
+}
+
+func NewStateMirrorAdapter(stor storage.Storage, opts ...Option) *StateMirrorAdapter {
+	return &StateMirrorAdapter{SimpleAdapter: NewSimpleAdapter(stor, opts...), storage: stor}
+}
+
+func (s *StateMirrorAdapter) Inject(data *parser.Data) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint, ok, err := s.StateCheckpoint(data.Channel)
+	if err != nil {
+		return err
+	}
+	if !ok && data.BlockNumber > 0 {
+		return fmt.Errorf("world state of channel %s is not mirrored: start the mirror from block 0", data.Channel)
+	}
+	if ok && data.BlockNumber > checkpoint+1 {
+		return fmt.Errorf("world state of channel %s is mirrored up to block %d: blocks %d-%d are missing",
+			data.Channel, checkpoint, checkpoint+1, data.BlockNumber-1)
+	}
+	batch := storage.NewBatch()
+	if err = s.inject(batch, data); err != nil {
+		return err
+	}
+	if ok && data.BlockNumber <= checkpoint {
+		// writes of the block have been applied
+		return s.storage.WriteBatch(batch)
+	}
+	if err = s.applyWrites(batch, data); err != nil {
+		return err
+	}
+	batch.Put(stateCheckpointPrefix+data.Channel, []byte(strconv.FormatUint(data.BlockNumber, 10)))
+	return s.storage.WriteBatch(batch)
+}
+
+// StateCheckpoint returns the number of the last block of the channel whose writes are applied to the mirror.
+func (s *StateMirrorAdapter) StateCheckpoint(channel string) (uint64, bool, error) {
+	return s.readCheckpoint(stateCheckpointPrefix + channel)
+}
+
+// applyWrites adds writes and deletes of valid transactions of the block to the batch in order.
+func (s *StateMirrorAdapter) applyWrites(batch *storage.Batch, data *parser.Data) error {
+	for i, tx := range data.Txs {
+		if !tx.IsValid() {
+			continue
+		}
+		txID, err := tx.TxId()
+		if err != nil {
+			logrus.Errorf("failed to get transaction id: %s", err)
+			continue
+		}
+		timestamp, err := tx.Timestamp()
+		if err != nil {
+			logrus.Errorf("failed to get transaction timestamp: %s", err)
+		}
+		actions, err := tx.Actions()
+		if err != nil {
+			logrus.Errorf("failed to get actions from transaction: %s", err)
+			continue
+		}
+		for _, action := range actions {
+			rwsets, err := action.RWSets()
+			if err != nil {
+				logrus.Errorf("failed to extract rwsets: %s", err)
+				continue
+			}
+			for _, rwset := range rwsets {
+				for _, write := range rwset.KVRWSet.Writes {
+					key := stateKey(data.Channel, rwset.NameSpace, write.Key)
+					if write.IsDelete {
+						batch.Delete(key)
+						continue
+					}
+					encoded, err := s.encode(&KeyModification{
+						Namespace:   rwset.NameSpace,
+						Key:         write.Key,
+						Value:       write.Value,
+						TxId:        txID,
+						BlockNumber: data.BlockNumber,
+						TxIndex:     i,
+						Timestamp:   timestamp,
+					})
+					if err != nil {
+						return err
+					}
+					batch.Put(key, encoded)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// State returns the current value of the key. storage.ErrNotFound is returned if the key doesn't exist.
+func (s *StateMirrorAdapter) State(channel, namespace, key string) ([]byte, error) {
+	value, err := s.storage.Get(stateKey(channel, namespace, key))
+	if err != nil {
+		return nil, err
+	}
+	modification := &KeyModification{}
+	if err := s.decode(value, modification); err != nil {
+		return nil, err
+	}
+	return modification.Value, nil
+}
+
+// StateRange returns the last writes of the keys of the namespace from startKey (inclusive) to endKey (exclusive) ordered by key,
+// as GetStateByRange of the chaincode shim does: empty startKey and endKey mean the first and the last keys of the namespace.
+func (s *StateMirrorAdapter) StateRange(channel, namespace, startKey, endKey string) ([]*KeyModification, error) {
+	opts := storage.IterateOptions{
+		Prefix: stateKey(channel, namespace, ""),
+		Start:  stateKey(channel, namespace, startKey),
+	}
+	if endKey != "" {
+		opts.End = stateKey(channel, namespace, endKey)
+	}
+	return s.scan(opts, nil, 0)
+}
+
+// StateByPrefix returns the last writes of the keys of the namespace with the prefix ordered by key.
+func (s *StateMirrorAdapter) StateByPrefix(channel, namespace, prefix string) ([]*KeyModification, error) {
+	return s.scan(storage.IterateOptions{Prefix: stateKey(channel, namespace, prefix)}, nil, 0)
+}
+
+// QueryState returns the last writes of the keys of the namespace which JSON values match the rich query (see StateQuery) ordered by key.
+func (s *StateMirrorAdapter) QueryState(channel, namespace, query string) ([]*KeyModification, error) {
+	parsed, err := ParseStateQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.scan(storage.IterateOptions{Prefix: stateKey(channel, namespace, "")}, parsed, parsed.Limit)
+}
+
+// scan returns the writes matching opts and the query (if any), at most limit of them if limit is positive.
+func (s *StateMirrorAdapter) scan(opts storage.IterateOptions, query *StateQuery, limit int) ([]*KeyModification, error) {
+	var writes []*KeyModification
+	opts.Limit = readPageSize
+	for {
+		page, err := s.storage.Iterate(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range page.KVs {
+			modification := &KeyModification{}
+			if err := s.decode(kv.Value, modification); err != nil {
+				return nil, err
+			}
+			if query != nil {
+				matched, err := query.Match(modification.Value)
+				if err != nil {
+					return nil, err
+				}
+				if !matched {
+					continue
+				}
+			}
+			writes = append(writes, modification)
+			if limit > 0 && len(writes) == limit {
+				return writes, nil
+			}
+		}
+		if page.Cursor == "" {
+			return writes, nil
+		}
+		opts.Cursor = page.Cursor
+	}
+}
+
+// stateKey returns storage key of the chaincode key. Chaincode keys are not escaped to keep their order
+// (namespaces, that is chaincode and collection names, can't contain '/').
+func stateKey(channel, namespace, key string) string {
+	return statePrefix + channel + "/" + namespace + "/" + key
+}
@@ -0,0 +1,153 @@
+/*
+Copyright LLC Newity. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storageadapter
+
+import (
+	"github.com/newity/crawler/parser"
+	"github.com/newity/crawler/storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func keysOf(writes []*KeyModification) []string {
+	var keys []string
+	for _, write := range writes {
+		keys = append(keys, write.Key)
+	}
+	return keys
+}
+
+// injectUpTo injects the block after empty blocks which precede it from the state checkpoint of its channel.
+func injectUpTo(t *testing.T, adapter *StateMirrorAdapter, data *parser.Data) {
+	checkpoint, ok, err := adapter.StateCheckpoint(data.Channel)
+	assert.NoError(t, err)
+	number := uint64(0)
+	if ok {
+		number = checkpoint + 1
+	}
+	for ; number < data.BlockNumber; number++ {
+		assert.NoError(t, adapter.Inject(&parser.Data{Channel: data.Channel, BlockNumber: number}))
+	}
+	assert.NoError(t, adapter.Inject(data))
+}
+
+func TestStateMirrorAdapterInject(t *testing.T) {
+	adapter := NewStateMirrorAdapter(newBadger(t))
+	data := parseBlock(t, "../blocklib/mock/sampleblock.pb")
+	injectUpTo(t, adapter, data)
+	injectUpTo(t, adapter, parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb"))
+
+	value, err := adapter.State("mychannel", "fabcar", "CAR11")
+	assert.NoError(t, err)
+	// writes of invalid transactions of block 35 (owner Archie) are not applied
+	assert.Contains(t, string(value), `"owner":"Mary"`)
+	checkpoint, _, err := adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(35), checkpoint)
+
+	// old block is saved without applying its writes and moving the checkpoint back
+	assert.NoError(t, adapter.storage.Delete(stateKey("mychannel", "fabcar", "CAR11")))
+	assert.NoError(t, adapter.Inject(data))
+	_, err = adapter.State("mychannel", "fabcar", "CAR11")
+	assert.Equal(t, storage.ErrNotFound, err)
+	checkpoint, _, err = adapter.Checkpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(35), checkpoint)
+
+	injectUpTo(t, adapter, parseBlock(t, "../blocklib/mock/withevents.pb"))
+	writes, err := adapter.StateRange("cc", "cc", "", "")
+	assert.NoError(t, err)
+	// the other key of the block is deleted
+	assert.Equal(t, []string{"\x002c\x00rCo9JsHePV6VsDBCSSLgyAt7hPKZTFtFpSR5TFGYn6dTosx75\x00FIAT\x00"}, keysOf(writes))
+	assert.Equal(t, uint64(64), writes[0].BlockNumber)
+	assert.Equal(t, "ecb9e0eb95fb799210c3f2465d1e02e4ac506cde523c4a1518bdbbae1f07f508", writes[0].TxId)
+	checkpoint, ok, err := adapter.StateCheckpoint("cc")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(64), checkpoint)
+}
+
+func TestStateMirrorAdapterFilledStorage(t *testing.T) {
+	stor := newBadger(t)
+	// blocks saved without the mirror
+	assert.NoError(t, NewSimpleAdapter(stor).Inject(parseBlock(t, "../blocklib/mock/sampleblock.pb")))
+
+	adapter := NewStateMirrorAdapter(stor)
+	assert.Error(t, adapter.Inject(parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb")))
+	_, ok, err := adapter.StateCheckpoint("mychannel")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	// the mirror doesn't start from the middle of the chain of an empty storage either
+	assert.Error(t, NewStateMirrorAdapter(newBadger(t)).Inject(parseBlock(t, "../blocklib/mock/sampleblock.pb")))
+
+	// the mirror is filled from block 0 again
+	injectUpTo(t, adapter, parseBlock(t, "../blocklib/mock/sampleblock.pb"))
+	value, err := adapter.State("mychannel", "fabcar", "CAR11")
+	assert.NoError(t, err)
+	assert.Contains(t, string(value), `"owner":"Mary"`)
+	checkpoint, _, err := adapter.StateCheckpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), checkpoint)
+
+	// writes are not applied over missing blocks
+	assert.Error(t, adapter.Inject(parseBlock(t, "../blocklib/mock/mvcc_read_conflict.pb")))
+	checkpoint, _, err = adapter.StateCheckpoint("mychannel")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), checkpoint)
+	_, err = adapter.RetrieveBlock("mychannel", 35)
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestStateMirrorAdapterQueries(t *testing.T) {
+	adapter := NewStateMirrorAdapter(newBadger(t))
+	for key, value := range map[string]string{
+		"CAR0":  `{"make":"Toyota","owner":"Tomoko","year":2015,"engine":{"fuel":"petrol"}}`,
+		"CAR1":  `{"make":"Ford","owner":"Brad","year":2008,"engine":{"fuel":"diesel"}}`,
+		"CAR10": `{"make":"VW","owner":"Mary","year":2019,"engine":{"fuel":"electric"}}`,
+		"CAR2":  `{"make":"Hyundai","owner":"Jin Soo","year":2012}`,
+		"MOTO0": `{"make":"Honda","owner":"Mary","year":2020}`,
+		"RAW":   `not json`,
+	} {
+		encoded, err := encodeWith(GobCodec{}, SchemaVersion, &KeyModification{Namespace: "fabcar", Key: key, Value: []byte(value)})
+		assert.NoError(t, err)
+		assert.NoError(t, adapter.storage.Put(stateKey("mychannel", "fabcar", key), encoded))
+	}
+
+	writes, err := adapter.StateRange("mychannel", "fabcar", "CAR1", "CAR2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CAR1", "CAR10"}, keysOf(writes))
+	writes, err = adapter.StateRange("mychannel", "fabcar", "CAR2", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CAR2", "MOTO0", "RAW"}, keysOf(writes))
+	writes, err = adapter.StateByPrefix("mychannel", "fabcar", "CAR")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CAR0", "CAR1", "CAR10", "CAR2"}, keysOf(writes))
+	writes, err = adapter.StateByPrefix("mychannel", "othercc", "")
+	assert.NoError(t, err)
+	assert.Empty(t, writes)
+
+	for query, expected := range map[string][]string{
+		`{"selector":{"owner":"Mary"}}`:                                            {"CAR10", "MOTO0"},
+		`{"selector":{"owner":"Mary"},"limit":1}`:                                  {"CAR10"},
+		`{"selector":{"year":{"$gte":2012,"$lt":2020}}}`:                           {"CAR0", "CAR10", "CAR2"},
+		`{"selector":{"engine.fuel":{"$in":["diesel","electric"]}}}`:               {"CAR1", "CAR10"},
+		`{"selector":{"engine":{"fuel":"petrol"}}}`:                                {"CAR0"},
+		`{"selector":{"engine":{"$exists":false}}}`:                                {"CAR2", "MOTO0"},
+		`{"selector":{"$or":[{"make":"Ford"},{"make":{"$regex":"^H"}}]}}`:          {"CAR1", "CAR2", "MOTO0"},
+		`{"selector":{"owner":{"$ne":"Mary"},"$not":{"make":"Ford"}}}`:             {"CAR0", "CAR2"},
+		`{"selector":{"$and":[{"owner":"Mary"},{"make":{"$nin":["VW"]}}]}}`:        {"MOTO0"},
+		`{"selector":{"$nor":[{"year":{"$lt":2015}},{"engine.fuel":"electric"}]}}`: {"CAR0", "MOTO0"},
+	} {
+		writes, err = adapter.QueryState("mychannel", "fabcar", query)
+		assert.NoError(t, err, query)
+		assert.Equal(t, expected, keysOf(writes), query)
+	}
+
+	for _, query := range []string{`{}`, `not json`, `{"selector":{"year":{"$near":1}}}`, `{"selector":{"$or":{"make":"VW"}}}`} {
+		_, err = adapter.QueryState("mychannel", "fabcar", query)
+		assert.Error(t, err, query)
+	}
+}